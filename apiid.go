@@ -1,6 +1,7 @@
 package kee
 
 import (
+    "errors"
     "math/big"
 )
 
@@ -8,60 +9,105 @@ import (
 // It is exported only for reference and should be instantiated through its handler's methods.
 type KAPIID struct {
     slc []byte
-    b58 string
+    opts *APIIDConfig
+    cache *encCache
     bigInt *big.Int
 }
 
 // APIIDConfig is the struct for APIIDOptions. It should only be used if  
 // another handler with a different set of options is being created.
-type apiidConfig struct {
-    Cache bool
+type APIIDConfig struct {
+    Cache, Checksum bool
+    B58Alphabet string
+    B58Check bool
 }
 
 // APIIDOptions defines the configuration used by the `kee.APIID` handler.
 // Options can also be changed through `kee.APIID.Options`.
-var APIIDOptions = apiidConfig {
+var APIIDOptions = APIIDConfig {
     Cache: true,            // Cache APIID strings, ignore new options
+    Checksum: false,        // Append check character to APIID strings
+    B58Alphabet: Base58Flickr, // Base 58 digits; see Base58Bitcoin, Base58Ripple
+    B58Check: false,        // Append Base58Check checksum to base 58 APIIDs
 }
 
 // APIIDCtrl is a struct for the APIID handler. 
 // Unless another handler with different options is needed simply use instance `kee.APIID`.
 type APIIDCtrl struct {
-    Options *apiidConfig
+    Options *APIIDConfig
+}
+
+// options returns the handler's config, falling back on APIIDOptions
+func (c APIIDCtrl) options() *APIIDConfig {
+    if c.Options == nil { return &APIIDOptions }
+    return c.Options
+}
+
+func (c APIIDCtrl) newInst(i *big.Int) KAPIID {
+    return KAPIID{slc: i.Bytes(), bigInt: i, opts: c.options(), cache: newEncCache()}
 }
 
 // FromString takes string representation of arbitrary precision integer and return KAPIID instance
 func (c APIIDCtrl) FromString(s string) KAPIID {
     i := new(big.Int)
     i.SetString(s, 10)
-    return KAPIID{slc: i.Bytes(), bigInt: i}
+    return c.newInst(i)
 }
 
 // FromInt takes 64-bit integer and return KAPIID instance
 func (c APIIDCtrl) FromInt(fpi uint64) KAPIID {
     i := new(big.Int)
     i.SetUint64(fpi)
-    return KAPIID{slc: i.Bytes(), bigInt: i}
+    return c.newInst(i)
 }
 
 // FromBigInt takes math/big Int and return KAPIID instance
 func (c APIIDCtrl) FromBigInt(api *big.Int) KAPIID {
     i := new(big.Int)
     i.Abs(api)
-    return KAPIID{slc: i.Bytes(), bigInt: i}
+    return c.newInst(i)
 }
 
-// Set takes an arbitrary-length byte slice and returns KAPIID instance
+// Set takes an arbitrary-length byte slice and returns KAPIID instance.
+// Leading zero bytes are kept and written as leading zero digits in base 58.
 func (c APIIDCtrl) Set(slc []byte) KAPIID {
     i := new(big.Int)
     i.SetBytes(slc)
-    return KAPIID{slc: i.Bytes(), bigInt: i}
+    res := c.newInst(i)
+    res.slc = append([]byte{}, slc...)
+    return res
 }
 
 // Decode takes base 58 encoded string of APIID and returns KAPIID instance
 func (c APIIDCtrl) Decode(s string) (KAPIID, error) {
-    i, err := b58ToBigInt([]byte(s))
-    return KAPIID{slc: i.Bytes(), bigInt: i}, err
+    return c.DecodeAs("b58", s)
+}
+
+// options returns the config of the handler that produced the APIID
+func (id KAPIID) options() *APIIDConfig {
+    if id.opts == nil { return &APIIDOptions }
+    return id.opts
+}
+
+// handler returns a handler sharing the config of the one that produced the APIID
+func (id KAPIID) handler() APIIDCtrl {
+    return APIIDCtrl{id.options()}
+}
+
+// DecodeAs takes an APIID encoded with the named encoding and returns KAPIID
+// instance. Built-in encodings are "b58", "b62", "b36" and "hex"; see
+// RegisterEncoding for others.
+func (c APIIDCtrl) DecodeAs(format, s string) (KAPIID, error) {
+    enc, ok := apiidEncoding(c.options(), format)
+    if !ok {
+        return c.newInst(new(big.Int)), &ParseError{format, -1, ParseUnknownFormat, nil}
+    }
+    if err := apiidCheckAlphabet(c.options(), format); err != nil {
+        return c.newInst(new(big.Int)), &ParseError{format, -1, ParseMalformed, err}
+    }
+    bytes, err := enc.DecodeString(s, 0)
+    if err != nil { return c.newInst(new(big.Int)), parseError(format, err) }
+    return c.Set(bytes), nil
 }
 
 // -- Produce --
@@ -82,10 +128,71 @@ func (id KAPIID) BigInt() (res *big.Int) {
     return id.bigInt
 }
 
+// Encode returns string representation of APIID in the named encoding, which
+// may be any of those DecodeAs accepts
+func (id KAPIID) Encode(name string) (string, error) {
+    enc, ok := apiidEncoding(id.options(), name)
+    if !ok { return "", errors.New("unknown APIID encoding " + name) }
+    if err := apiidCheckAlphabet(id.options(), name); err != nil { return "", err }
+    if id.slc == nil || len(id.slc) == 0    { return "", nil }
+    return id.cached(name, func() string {
+        return enc.EncodeToString(id.slc)
+    }), nil
+}
+
 // B58 returns base 58 encoded string representation of APIID
 func (id KAPIID) B58() (res string) {
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if APIIDOptions.Cache && id.b58 != "" { return id.b58 }
-    id.b58 = string(bigIntToB58(nil, id.bigInt))
-    return id.b58
-}
\ No newline at end of file
+    res, _ = id.Encode("b58")
+    return
+}
+
+// B62 returns base 62 encoded string representation of APIID, made only of
+// letters and digits
+func (id KAPIID) B62() (res string) {
+    res, _ = id.Encode("b62")
+    return
+}
+
+// B36 returns lower case base 36 encoded string representation of APIID
+func (id KAPIID) B36() (res string) {
+    res, _ = id.Encode("b36")
+    return
+}
+
+// cached returns the string stored under key, encoding it with enc first if
+// there is none or caching is off
+func (id KAPIID) cached(key string, enc func() string) string {
+    return id.cache.get(id.options().Cache, key, enc)
+}
+
+// apiidEncoding returns the named encoding, with a check character if opts
+// asks for one; being of arbitrary length, APIIDs are never zero-padded
+func apiidEncoding(opts *APIIDConfig, name string) (Encoding, bool) {
+    var enc Encoding
+    ok := true
+    switch name {
+    case "b58":
+        enc = base58Encoding{alphabet: apiidB58Alphabet(opts), check: opts.B58Check}
+    case "b62":
+        enc = radixEncoding{alphabet: b62Alphabet}
+    case "b36":
+        enc = radixEncoding{alphabet: b36Alphabet, fold: true}
+    default:
+        enc, ok = LookupEncoding(name)
+    }
+    if ok && opts.Checksum { enc = withChecksum(enc, name, false) }
+    return enc, ok
+}
+
+// apiidB58Alphabet returns the base 58 alphabet of opts, or the default one
+func apiidB58Alphabet(opts *APIIDConfig) string {
+    if opts.B58Alphabet == "" { return b58Alphabet }
+    return opts.B58Alphabet
+}
+
+// apiidCheckAlphabet returns an error if the named encoding is base 58 and
+// opts has an unusable alphabet for it
+func apiidCheckAlphabet(opts *APIIDConfig, name string) error {
+    if name != "b58" { return nil }
+    return checkB58Alphabet(apiidB58Alphabet(opts))
+}
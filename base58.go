@@ -0,0 +1,79 @@
+package kee
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "errors"
+)
+
+// Base 58 alphabets. Each leaves out characters that are easily confused,
+// but they order the rest differently, so strings only interoperate between
+// systems that use the same one. Base58Flickr is the one kee has always used.
+const (
+    Base58Bitcoin = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz" // Bitcoin, IPFS
+    Base58Flickr  = "123456789abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ" // Flickr short URLs
+    Base58Ripple  = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz" // Ripple addresses
+)
+
+const b58Alphabet = Base58Flickr
+
+// base58Encoding writes bytes as a big-endian number in base 58, with one
+// leading zero digit for every leading zero byte, as Bitcoin does. With check,
+// the first 4 bytes of the double SHA-256 of the bytes are appended before
+// encoding (Base58Check) and verified when decoding.
+type base58Encoding struct {
+    alphabet string
+    check bool
+}
+
+// checkB58Alphabet returns an error unless alphabet is 58 distinct characters
+func checkB58Alphabet(alphabet string) error {
+    if len(alphabet) != 58 { return errors.New("base 58 alphabet must have 58 characters") }
+    for i := 0; i < len(alphabet); i++ {
+        if alphabet[i] >= 0x80 || bytes.IndexByte([]byte(alphabet[:i]), alphabet[i]) >= 0 {
+            return errors.New("base 58 alphabet must have distinct ASCII characters")
+        }
+    }
+    return nil
+}
+
+// b58Checksum returns the first 4 bytes of the double SHA-256 of src
+func b58Checksum(src []byte) []byte {
+    first := sha256.Sum256(src)
+    second := sha256.Sum256(first[:])
+    return second[:4]
+}
+
+func (e base58Encoding) EncodeToString(src []byte) string {
+    if e.check { src = append(append([]byte{}, src...), b58Checksum(src)...) }
+    zeros := 0
+    for zeros < len(src) && src[zeros] == 0 { zeros++ }
+    return string(bytes.Repeat([]byte{e.alphabet[0]}, zeros)) +
+        radixEncode(src[zeros:], e.alphabet, 0)
+}
+
+func (e base58Encoding) DecodeString(s string, size int) ([]byte, error) {
+    zeros := 0
+    for zeros < len(s) && s[zeros] == e.alphabet[0] { zeros++ }
+    dst := make([]byte, zeros)
+    if zeros < len(s) {
+        rest, err := radixDecode(s[zeros:], e.alphabet, 0)
+        if err != nil {
+            if pe, ok := err.(*ParseError); ok && pe.Offset >= 0 { pe.Offset += zeros }
+            return []byte{}, err
+        }
+        dst = append(dst, rest...)
+    }
+    if e.check {
+        if len(dst) < 4 { return []byte{}, newParseError(ParseBadLength, -1) }
+        n := len(dst) - 4
+        if !bytes.Equal(dst[n:], b58Checksum(dst[:n])) {
+            return []byte{}, newParseError(ParseBadCheck, -1)
+        }
+        dst = dst[:n]
+    }
+    if size > len(dst) { // strings written without leading zero digits
+        dst = append(make([]byte, size - len(dst)), dst...)
+    }
+    return checkSize(dst, size)
+}
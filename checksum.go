@@ -0,0 +1,86 @@
+package kee
+
+import (
+    "strings"
+)
+
+// luhnEncoding appends a Luhn mod N check character, drawn from the alphabet
+// of the encoding it wraps, to every string and verifies it when decoding.
+// It catches any single mistyped character and most swaps of adjacent ones.
+// Padding is dropped so that the check character always comes last.
+type luhnEncoding struct {
+    enc Encoding
+    alphabet string
+    norm func(string) string // Maps a string onto the alphabet, dropping the rest
+}
+
+// withChecksum wraps the named built-in encoding with a check character; other
+// encodings are returned unchanged
+func withChecksum(enc Encoding, name string, sort bool) Encoding {
+    upper := func(s string) string {
+        return strings.NewReplacer(" ", "", "-", "", "=", "").Replace(strings.ToUpper(s))
+    }
+    switch name {
+    case "b64", "url64":
+        if sort {
+            return luhnEncoding{enc, b64SortAlphabet, func(s string) string {
+                return strings.Replace(s, "=", "", -1)
+            }}
+        }
+        if name == "url64" {
+            return luhnEncoding{enc, url64Alphabet, func(s string) string {
+                return b64ToURL64(s)
+            }}
+        }
+        return luhnEncoding{enc, b64Alphabet, func(s string) string {
+            return strings.Replace(url64ToB64(s), "=", "", -1)
+        }}
+    case "b32", "url32":
+        if sort { return luhnEncoding{enc, b32HexAlphabet, upper} }
+        return luhnEncoding{enc, b32Alphabet, upper}
+    case "b58":
+        alphabet := b58Alphabet
+        if b, ok := enc.(base58Encoding); ok { alphabet = b.alphabet }
+        return luhnEncoding{enc, alphabet, func(s string) string { return s }}
+    case "b62":
+        return luhnEncoding{enc, b62Alphabet, func(s string) string { return s }}
+    case "b36":
+        return luhnEncoding{enc, b36Alphabet, strings.ToLower}
+    }
+    return enc
+}
+
+func (e luhnEncoding) EncodeToString(src []byte) string {
+    s := strings.TrimRight(e.enc.EncodeToString(src), "=")
+    check, _ := luhnCheck(e.norm(s), e.alphabet)
+    return s + string(e.alphabet[check])
+}
+
+func (e luhnEncoding) DecodeString(s string, size int) ([]byte, error) {
+    if len(s) < 2 { return []byte{}, newParseError(ParseBadLength, -1) }
+    body, last := s[:len(s)-1], e.norm(s[len(s)-1:])
+    check, i := luhnCheck(e.norm(body), e.alphabet)
+    if i >= 0 { // let the encoding locate the character it can't read
+        if _, err := e.enc.DecodeString(body, size); err != nil { return []byte{}, err }
+        return []byte{}, newParseError(ParseIllegalChar, -1)
+    }
+    if last == "" || last[0] != e.alphabet[check] {
+        return []byte{}, newParseError(ParseBadCheck, len(s)-1)
+    }
+    return e.enc.DecodeString(body, size)
+}
+
+// luhnCheck returns the index in alphabet of the Luhn mod N check character
+// for s, or the offset of the first character of s not in alphabet
+func luhnCheck(s, alphabet string) (int, int) {
+    n := len(alphabet)
+    sum, factor := 0, 2
+    for i := len(s) - 1; i >= 0; i-- {
+        cp := strings.IndexByte(alphabet, s[i])
+        if cp < 0 { return 0, i }
+        addend := factor * cp
+        sum += addend / n + addend % n
+        factor = 3 - factor
+    }
+    return (n - sum % n) % n, -1
+}
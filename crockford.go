@@ -0,0 +1,88 @@
+package kee
+
+import (
+    "math/big"
+    "strings"
+)
+
+// Crockford's base 32 alphabet omits I, L, O and U to avoid confusion with
+// 1, 0 and obscenity; the check symbols extend it to 37 for a mod 37 checksum.
+const (
+    crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+    crockfordCheckSymbols = crockfordAlphabet + "*~$=U"
+)
+
+var crockfordDecodeMap [256]byte
+
+func init() {
+    for i := 0; i < len(crockfordDecodeMap); i++ {
+        crockfordDecodeMap[i] = 0xFF
+    }
+    for i := 0; i < len(crockfordCheckSymbols); i++ {
+        crockfordDecodeMap[crockfordCheckSymbols[i]] = byte(i)
+    }
+}
+
+// crockfordEncode returns big-endian src as a number in Crockford base 32,
+// zero-padded to the width needed for len(src) bytes, optionally followed by
+// its mod 37 check symbol
+func crockfordEncode(src []byte, check bool) string {
+    n := new(big.Int).SetBytes(src)
+    width := crockfordWidth(len(src))
+    res := make([]byte, width, width+1)
+    v := new(big.Int).Set(n)
+    mod, radix := new(big.Int), big.NewInt(32)
+    for i := width - 1; i >= 0; i-- {
+        v.DivMod(v, radix, mod)
+        res[i] = crockfordAlphabet[mod.Int64()]
+    }
+    if check {
+        mod.Mod(n, big.NewInt(37))
+        res = append(res, crockfordCheckSymbols[mod.Int64()])
+    }
+    return string(res)
+}
+
+// crockfordDecode decodes Crockford base 32 string s into size big-endian
+// bytes. Hyphens are ignored and confusable characters normalized; a trailing
+// check symbol, recognized by length, is verified.
+func crockfordDecode(s string, size int) ([]byte, error) {
+    src := s
+    s = crockfordNormalize(s)
+    width := crockfordWidth(size)
+    if len(s) != width && len(s) != width+1 {
+        return []byte{}, newParseError(ParseBadLength, -1)
+    }
+    n, radix := new(big.Int), big.NewInt(32)
+    for i := 0; i < width; i++ {
+        b := crockfordDecodeMap[s[i]]
+        if b > 31 {
+            return []byte{}, newParseError(ParseIllegalChar, sourceOffset(src, "- ", i))
+        }
+        n.Mul(n, radix)
+        n.Add(n, big.NewInt(int64(b)))
+    }
+    if n.BitLen() > size * 8 {
+        return []byte{}, newParseError(ParseBadLength, -1)
+    }
+    if len(s) == width+1 {
+        mod := new(big.Int).Mod(n, big.NewInt(37))
+        if crockfordDecodeMap[s[width]] != byte(mod.Int64()) {
+            return []byte{}, newParseError(ParseBadCheck, sourceOffset(src, "- ", width))
+        }
+    }
+    return n.FillBytes(make([]byte, size)), nil
+}
+
+// crockfordNormalize removes hyphens and spaces, upper-cases s and maps the
+// confusable characters O to 0 and I and L to 1
+func crockfordNormalize(s string) string {
+    return strings.NewReplacer(
+        "-", "", " ", "", "O", "0", "I", "1", "L", "1",
+    ).Replace(strings.ToUpper(s))
+}
+
+// crockfordWidth returns the number of characters needed for size bytes
+func crockfordWidth(size int) int {
+    return (size * 8 + 4) / 5
+}
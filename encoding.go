@@ -0,0 +1,355 @@
+package kee
+
+import (
+    "encoding/ascii85"
+    "encoding/base32"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// Encoding converts the bytes of an ID to and from a string. Encodings are
+// registered by name with RegisterEncoding and used through the `Encode`
+// method of the ID types and the `DecodeAs` method of their handlers.
+//
+// UUIDs pass their 16 bytes, APIIDs their big-endian bytes and FPIIDs their
+// value as big-endian bytes, trimmed to 16 or 32 bits if ShortStr is set.
+type Encoding interface {
+    // EncodeToString returns the string form of src.
+    EncodeToString(src []byte) string
+    // DecodeString returns the bytes encoded in s. If size is not 0 an error
+    // is returned unless exactly size bytes were decoded.
+    DecodeString(s string, size int) ([]byte, error)
+}
+
+var encodings = struct {
+    sync.RWMutex
+    m map[string]Encoding
+}{m: make(map[string]Encoding)}
+
+func init() {
+    for name, enc := range map[string]Encoding{
+        "hex":       hexEncoding{},
+        "a85":       a85Encoding{},
+        "b64":       base64Encoding{pad: true},
+        "url64":     base64Encoding{url: true},
+        "b32":       base32Encoding{pad: true},
+        "url32":     base32Encoding{hyph: true},
+        "crockford": crockfordEncoding{},
+        "b58":       base58Encoding{alphabet: b58Alphabet},
+        "b62":       radixEncoding{alphabet: b62Alphabet, pad: true},
+        "b36":       radixEncoding{alphabet: b36Alphabet, pad: true, fold: true},
+    } {
+        encodings.m[name] = enc
+    }
+}
+
+// RegisterEncoding makes enc available under name to the `Encode` and
+// `DecodeAs` methods of all ID types. Names cannot be registered twice.
+func RegisterEncoding(name string, enc Encoding) error {
+    if name == "" || enc == nil {
+        return errors.New("encoding needs a name and an implementation")
+    }
+    encodings.Lock()
+    defer encodings.Unlock()
+    if _, ok := encodings.m[name]; ok {
+        return errors.New("encoding " + name + " already registered")
+    }
+    encodings.m[name] = enc
+    return nil
+}
+
+// LookupEncoding returns the encoding registered under name
+func LookupEncoding(name string) (Encoding, bool) {
+    encodings.RLock()
+    defer encodings.RUnlock()
+    enc, ok := encodings.m[name]
+    return enc, ok
+}
+
+// -- Parse errors --
+
+// ParseReason tells why an encoded ID could not be decoded
+type ParseReason int
+
+const (
+    ParseMalformed      = ParseReason(iota) // Rejected by the encoding for another reason
+    ParseUnknownFormat                      // No encoding of that name
+    ParseBadLength                          // Wrong number of characters or bytes
+    ParseIllegalChar                        // Character outside the encoding's alphabet
+    ParseBadPadding                         // Misplaced or incomplete padding
+    ParseBadCheck                           // Check symbol does not match the value
+    ParseInvalidVersion                     // Decoded, but not a valid ID
+)
+
+func (r ParseReason) String() string {
+    switch r {
+    case ParseUnknownFormat:
+        return "unknown encoding"
+    case ParseBadLength:
+        return "bad length"
+    case ParseIllegalChar:
+        return "illegal character"
+    case ParseBadPadding:
+        return "bad padding"
+    case ParseBadCheck:
+        return "check symbol mismatch"
+    case ParseInvalidVersion:
+        return "invalid version"
+    }
+    return "malformed input"
+}
+
+// ParseError is returned by the `Decode` and `DecodeAs` methods of the ID
+// handlers when a string cannot be decoded
+type ParseError struct {
+    Format string          // Name of the encoding tried, empty if none fit
+    Offset int             // Offending byte of the input, or -1 if none
+    Reason ParseReason
+    Err error              // Underlying error, if any
+}
+
+func (e *ParseError) Error() string {
+    format := e.Format
+    if format == "" { format = "encoded" }
+    if e.Reason == ParseUnknownFormat { return "unknown encoding " + format }
+    msg := e.Reason.String() + " in " + format + " string"
+    if e.Offset >= 0 { msg += " at input byte " + strconv.Itoa(e.Offset) }
+    if e.Err != nil { msg += ": " + e.Err.Error() }
+    return msg
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+    return e.Err
+}
+
+// newParseError returns a ParseError with no format, to be named by the handler
+func newParseError(reason ParseReason, offset int) *ParseError {
+    return &ParseError{Offset: offset, Reason: reason}
+}
+
+// parseError returns err as a ParseError for the named format
+func parseError(format string, err error) error {
+    switch e := err.(type) {
+    case *ParseError:
+        res := *e
+        res.Format = format
+        return &res
+    }
+    return &ParseError{format, -1, ParseMalformed, err}
+}
+
+// parseReason returns the reason of err if it is a ParseError
+func parseReason(err error) ParseReason {
+    var e *ParseError
+    if errors.As(err, &e) { return e.Reason }
+    return ParseMalformed
+}
+
+// checkSize returns dst, or an error if size is set and dst has another length
+func checkSize(dst []byte, size int) ([]byte, error) {
+    if size != 0 && len(dst) != size {
+        return []byte{}, newParseError(ParseBadLength, -1)
+    }
+    return dst, nil
+}
+
+// checkAlphabet returns an error at the first byte of s not in alphabet,
+// ignoring case if fold is set
+func checkAlphabet(s, alphabet string, fold bool) error {
+    for i := 0; i < len(s); i++ {
+        c := s[i]
+        if fold && 'a' <= c && c <= 'z' { c -= 'a' - 'A' }
+        if strings.IndexByte(alphabet, c) < 0 {
+            return newParseError(ParseIllegalChar, i)
+        }
+    }
+    return nil
+}
+
+// checkPadding returns an error if s has padding anywhere but at its end, or
+// if padding leaves s, less the bytes in cut, short of a multiple of block
+func checkPadding(s, cut string, block int) error {
+    i := strings.IndexByte(s, '=')
+    if i < 0 { return nil }
+    for j := i; j < len(s); j++ {
+        if s[j] != '=' && strings.IndexByte(cut, s[j]) < 0 {
+            return newParseError(ParseBadPadding, j)
+        }
+    }
+    n := 0
+    for j := 0; j < len(s); j++ {
+        if strings.IndexByte(cut, s[j]) < 0 { n++ }
+    }
+    if n % block != 0 { return newParseError(ParseBadPadding, i) }
+    return nil
+}
+
+// sourceOffset returns the offset in s of byte i of s with the bytes in cut
+// removed, so errors point at the caller's input
+func sourceOffset(s, cut string, i int) int {
+    for j := 0; j < len(s); j++ {
+        if strings.IndexByte(cut, s[j]) >= 0 { continue }
+        if i == 0 { return j }
+        i--
+    }
+    return len(s)
+}
+
+// -- Built-in encodings --
+
+const (
+    b64Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+    b32Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+    url64Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+    // URL-safe and in ASCII order, so equal-length strings sort like the bytes
+    b64SortAlphabet = "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
+    b32HexAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUV"
+)
+
+var b64Sort = base64.NewEncoding(b64SortAlphabet)
+
+// hexEncoding is plain lower case hex; hyphens are ignored when decoding
+type hexEncoding struct{}
+
+func (hexEncoding) EncodeToString(src []byte) string {
+    return hex.EncodeToString(src)
+}
+
+func (hexEncoding) DecodeString(s string, size int) ([]byte, error) {
+    for i := 0; i < len(s); i++ {
+        if _, ok := fromHexChar(s[i]); !ok && s[i] != '-' {
+            return []byte{}, newParseError(ParseIllegalChar, i)
+        }
+    }
+    dst, err := hex.DecodeString(strings.Replace(s, "-", "", -1))
+    if err != nil { return []byte{}, newParseError(ParseBadLength, -1) }
+    return checkSize(dst, size)
+}
+
+// a85Encoding is ASCII 85, optionally wrapped with <~ ~>
+type a85Encoding struct {
+    wrap bool
+}
+
+func (e a85Encoding) EncodeToString(src []byte) string {
+    dst := make([]byte, ascii85.MaxEncodedLen(len(src)))
+    dst = dst[:ascii85.Encode(dst, src)]
+    if e.wrap { return strings.Join([]string{"<~", string(dst), "~>"}, "") }
+    return string(dst)
+}
+
+func (a85Encoding) DecodeString(s string, size int) ([]byte, error) {
+    skip := 0
+    if strings.HasPrefix(s, "<~") && strings.HasSuffix(s, "~>") {
+        s, skip = s[2:len(s)-2], 2
+    }
+    dst := make([]byte, 4 * len(s))
+    n, _, err := ascii85.Decode(dst, []byte(s), true)
+    if e, ok := err.(ascii85.CorruptInputError); ok {
+        return []byte{}, newParseError(ParseIllegalChar, int(e) + skip)
+    }
+    if err != nil { return []byte{}, err }
+    return checkSize(dst[:n], size)
+}
+
+// base64Encoding is standard or URL-safe base 64, optionally padded; either
+// alphabet and padding are accepted when decoding. With sort, the alphabet is
+// the sortable one instead.
+type base64Encoding struct {
+    url, pad, sort bool
+}
+
+func (e base64Encoding) EncodeToString(src []byte) string {
+    if e.sort {
+        res := b64Sort.EncodeToString(src)
+        if e.url || !e.pad { res = strings.Replace(res, "=", "", -1) }
+        return res
+    }
+    res := base64.StdEncoding.EncodeToString(src)
+    if e.url { return b64ToURL64(res) }
+    if !e.pad { res = strings.Replace(res, "=", "", -1) }
+    return res
+}
+
+func (e base64Encoding) DecodeString(s string, size int) ([]byte, error) {
+    alphabet, enc := b64Alphabet + "-_=", base64.RawStdEncoding
+    if e.sort { alphabet, enc = b64SortAlphabet + "=", b64Sort.WithPadding(base64.NoPadding) }
+    if err := checkAlphabet(s, alphabet, false); err != nil { return []byte{}, err }
+    if err := checkPadding(s, "", 4); err != nil { return []byte{}, err }
+    if !e.sort { s = url64ToB64(s) }
+    dst, err := enc.DecodeString(strings.Replace(s, "=", "", -1))
+    if err != nil { return []byte{}, newParseError(ParseBadLength, -1) }
+    return checkSize(dst, size)
+}
+
+// base32Encoding is standard base 32, or the sortable "extended hex" base 32
+// of RFC 4648 if hex is set, optionally padded or hyphenated every 4
+// characters; case, padding, spaces and hyphens are ignored when decoding
+type base32Encoding struct {
+    pad, hyph, hex bool
+}
+
+func (e base32Encoding) EncodeToString(src []byte) string {
+    enc := base32.StdEncoding
+    if e.hex { enc = base32.HexEncoding }
+    res := enc.EncodeToString(src)
+    if !e.pad || e.hyph { res = strings.Replace(res, "=", "", -1) }
+    if e.hyph { res = hyphenate(res, 4) }
+    return res
+}
+
+func (e base32Encoding) DecodeString(s string, size int) ([]byte, error) {
+    alphabet, enc := b32Alphabet, base32.StdEncoding
+    if e.hex { alphabet, enc = b32HexAlphabet, base32.HexEncoding }
+    if err := checkAlphabet(s, alphabet + " -=", true); err != nil {
+        return []byte{}, err
+    }
+    if err := checkPadding(s, " -", 8); err != nil { return []byte{}, err }
+    s = strings.NewReplacer(" ", "", "-", "", "=", "").Replace(strings.ToUpper(s))
+    dst, err := enc.WithPadding(base32.NoPadding).DecodeString(s)
+    if err != nil { return []byte{}, newParseError(ParseBadLength, -1) }
+    return checkSize(dst, size)
+}
+
+// crockfordEncoding is Crockford base 32, optionally with check symbol, which
+// require makes mandatory when decoding; it needs a size to decode
+type crockfordEncoding struct {
+    check, require bool
+}
+
+func (e crockfordEncoding) EncodeToString(src []byte) string {
+    return crockfordEncode(src, e.check)
+}
+
+func (e crockfordEncoding) DecodeString(s string, size int) ([]byte, error) {
+    if size == 0 { return []byte{}, newParseError(ParseBadLength, -1) }
+    if e.require && len(crockfordNormalize(s)) == crockfordWidth(size) {
+        return []byte{}, newParseError(ParseBadCheck, len(s))
+    }
+    return crockfordDecode(s, size)
+}
+
+// radixEncoding writes bytes as a big-endian number in the base of alphabet,
+// optionally zero-padded to a fixed width; fold decodes case-insensitively
+// for lower case alphabets
+type radixEncoding struct {
+    alphabet string
+    pad, fold bool
+}
+
+func (e radixEncoding) EncodeToString(src []byte) string {
+    width := 0
+    if e.pad { width = radixWidth(len(src), int64(len(e.alphabet))) }
+    return radixEncode(src, e.alphabet, width)
+}
+
+func (e radixEncoding) DecodeString(s string, size int) ([]byte, error) {
+    if e.fold { s = strings.ToLower(s) }
+    return radixDecode(s, e.alphabet, size)
+}
@@ -0,0 +1,46 @@
+package kee
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/binary"
+)
+
+// A balanced Feistel network keyed with HMAC-SHA256 gives a permutation of
+// the integers of a given even width: every value maps to exactly one other of
+// the same width and only the key maps it back.
+
+const feistelRounds = 8
+
+// feistelEncrypt permutes the lowest bits bits of v under key
+func feistelEncrypt(v uint64, bits uint, key []byte) uint64 {
+    half := bits / 2
+    mask := uint64(1) << half - 1
+    l, r := v >> half & mask, v & mask
+    for i := 0; i < feistelRounds; i++ {
+        l, r = r, l ^ feistelRound(key, bits, i, r) & mask
+    }
+    return l << half | r
+}
+
+// feistelDecrypt reverses feistelEncrypt
+func feistelDecrypt(v uint64, bits uint, key []byte) uint64 {
+    half := bits / 2
+    mask := uint64(1) << half - 1
+    l, r := v >> half & mask, v & mask
+    for i := feistelRounds - 1; i >= 0; i-- {
+        l, r = r ^ feistelRound(key, bits, i, l) & mask, l
+    }
+    return l << half | r
+}
+
+// feistelRound returns the round function of round i for half block x; the
+// width is mixed in so that each width gets an unrelated permutation
+func feistelRound(key []byte, bits uint, i int, x uint64) uint64 {
+    var msg [10]byte
+    msg[0], msg[1] = byte(bits), byte(i)
+    binary.BigEndian.PutUint64(msg[2:], x)
+    mac := hmac.New(sha256.New, key)
+    mac.Write(msg[:])
+    return binary.BigEndian.Uint64(mac.Sum(nil))
+}
@@ -2,20 +2,17 @@ package kee
 
 import(
     "encoding/binary"
-    "encoding/base64"
-    "encoding/base32"
     "strings"
     "errors"
+    "time"
 )
 
 // KFPIID type represents a fixed precision integer identifier.
 // It is exported only for reference and should be instantiated through its handler's methods.
 type KFPIID struct {
     slc []byte
-    b64 string
-    b32 string
-    url64 string
-    url32 string
+    opts *FPIIDConfig
+    cache *encCache
 }
 
 const ( // Maximum values for signed ints
@@ -24,11 +21,28 @@ const ( // Maximum values for signed ints
     maxVal64 uint64 = 18446744073709551615
 )
 
+// FPIIDByteOrder selects how the base 64 and base 32 forms of FPIIDs lay out
+// their bytes
+type FPIIDByteOrder uint8
+
+const (
+    FPIIDLittleEndian = FPIIDByteOrder(iota) // Least significant byte first
+    FPIIDBigEndian                           // Most significant byte first
+    FPIIDSortable                            // Big-endian, full width, in alphabets sorted like ASCII
+)
+
 // FPIIDConfig is the struct for FPIIDOptions. It should only be used if  
 // another handler with a different set of options is being created.
 type FPIIDConfig struct {
-    Cache, ShortStr bool
+    Cache, ShortStr, Signed, Checksum bool
     PadB64, PadB32, HyphURL32 bool
+    CrockfordCheck bool
+    ByteOrder FPIIDByteOrder
+    Epoch time.Time
+    TimeBits, WorkerBits, SeqBits uint
+    WorkerID uint64
+    Obfuscate bool
+    Secret []byte
 }
 
 // FPIIDOptions defines the configuration used by the `kee.FPIID` handler.
@@ -36,9 +50,20 @@ type FPIIDConfig struct {
 var FPIIDOptions = FPIIDConfig {
     Cache: true,            // Cache FPIID strings, ignore new options
     ShortStr: true,         // Try conversion to uint32/16 for strings
+    Signed: false,          // Zig-zag encode values as int64 for strings
+    Checksum: false,        // Append check character to FPIID strings
     PadB64: true,           // Add padding to base 64 encoded FPIIDs
     PadB32: true,           // Add padding to base 32 encoded FPIIDs
     HyphURL32: true,        // Hyphenate base 32 encoded URL FPIIDs
+    CrockfordCheck: true,   // Append check symbol to Crockford base 32 FPIIDs
+    ByteOrder: FPIIDLittleEndian, // Byte order of base 64/32 FPIIDs
+    Epoch: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), // Zero time of new FPIIDs
+    TimeBits: 41,           // Bits of milliseconds since Epoch in new FPIIDs
+    WorkerBits: 10,         // Bits of worker ID in new FPIIDs
+    SeqBits: 12,            // Bits of per-millisecond sequence in new FPIIDs
+    WorkerID: 0,            // Worker ID of this process, unique among workers
+    Obfuscate: false,       // Permute FPIID values in strings, keyed by Secret
+    Secret: nil,            // Key for Obfuscate; keep private and never change
 }
 
 // FPIIDCtrl is a struct for the APIID handler. 
@@ -47,42 +72,99 @@ type FPIIDCtrl struct {
     Options *FPIIDConfig
 }
 
+// options returns the handler's config, falling back on FPIIDOptions
+func (c FPIIDCtrl) options() *FPIIDConfig {
+    if c.Options == nil { return &FPIIDOptions }
+    return c.Options
+}
+
+func (c FPIIDCtrl) newInst(bytes []byte) KFPIID {
+    return KFPIID{slc: bytes, opts: c.options(), cache: newEncCache()}
+}
+
 // FromInt takes a [8]byte array and returns a KFPIID instance
 func (c FPIIDCtrl) FromInt(id uint64) KFPIID {
     bytes := make([]byte, 8)
     binary.LittleEndian.PutUint64(bytes, uint64(id))
-    return KFPIID{slc: bytes}
+    return c.newInst(bytes)
+}
+
+// FromInt64 takes a signed 64-bit integer and returns a KFPIID instance holding
+// its two's complement; set the Signed option for short strings of small
+// negative values
+func (c FPIIDCtrl) FromInt64(id int64) KFPIID {
+    return c.FromInt(uint64(id))
 }
 
 // Set takes an [8]byte array and returns a KFPIID instance
 func (c FPIIDCtrl) Set(arr [8]byte) KFPIID {
     bytes := make([]byte, 8)
     bytes = arr[:]
-    return KFPIID{slc: bytes}
+    return c.newInst(bytes)
 }
 
-// Decode takes encoded string of FPIID and returns KFPIID instance
+// Decode takes encoded string of FPIID and returns KFPIID instance. The encoding
+// is guessed from the length of the string; use DecodeAs where lengths are shared.
+// Crockford base 32 is recognized only with its check symbol, unless Checksum is on.
+// Signed strings are recognized by their leading mark, whatever the handler.
 func (c FPIIDCtrl) Decode(s string) (KFPIID, error) {
+    format, body := "b32", strings.TrimPrefix(s, fpiidSignedMark)
+    if c.options().Checksum && len(body) > 0 { body = body[:len(body)-1] }
+    switch len(strings.Replace(body, "=", "", -1)) {
+    case 3, 6, 11:  // B64 uint16, uint32, uint64
+        format = "b64"
+    default:        // B32, or Crockford B32 with check symbol; hyphens allowed
+        n := len(strings.NewReplacer("-", "", " ", "").Replace(body))
+        if !c.options().Checksum && n == crockfordWidth(8) + 1 { format = "crockford" }
+    }
+    return c.DecodeAs(format, s)
+}
+
+// DecodeAs takes an FPIID encoded with the named encoding and returns KFPIID
+// instance. Built-in encodings are "b64", "url64", "b32", "url32", "crockford",
+// "b62", "b36" and "b58"; see RegisterEncoding for others.
+func (c FPIIDCtrl) DecodeAs(format, s string) (KFPIID, error) {
+    enc, ok := fpiidEncoding(c.options(), format)
+    if !ok {
+        return c.newInst([]byte{}), &ParseError{format, -1, ParseUnknownFormat, nil}
+    }
+    if err := fpiidCheckSecret(c.options()); err != nil { return c.newInst([]byte{}), err }
+    skip := 0
+    if strings.HasPrefix(s, fpiidSignedMark) { s, skip = s[len(fpiidSignedMark):], len(fpiidSignedMark) }
     var bytes []byte
     var err error
-    tmp := strings.Replace(s, "=", "", -1)
-    switch len(tmp) {
-    case 3:     // B64 uint16 // len 4 with pad
-        bytes, err = c.fromB64(s, 16, 4)
-    case 4:     // B32 uint16 // len 8 with pad
-        bytes, err = c.fromB32(s, 16, 8)
-    case 6:     // B64 uint32 // len 8 with pad
-        bytes, err = c.fromB64(s, 32, 8)
-    case 7:     // B32 uint32 // len 8 with pad
-        bytes, err = c.fromB32(s, 32, 8)
-    case 11:    // B64 uint64 // len 12 with pad
-        bytes, err = c.fromB64(s, 64, 12)
-    case 13:    // B32 uint64 // len 16 with pad
-        bytes, err = c.fromB32(s, 64, 16)
-    default:
-        return KFPIID{slc: []byte{}}, errors.New("unrecognized FPIID encoding")
+    for _, size := range []int{8, 4, 2} { // uint64, or uint32/16 if ShortStr
+        var e error
+        if bytes, e = enc.DecodeString(s, size); e == nil {
+            err = nil
+            break
+        }
+        // Report why s fails to decode rather than that it fits no size
+        if err == nil || parseReason(err) == ParseBadLength { err = e }
+    }
+    if err != nil {
+        pe := parseError(format, err).(*ParseError)
+        if pe.Offset >= 0 { pe.Offset += skip }
+        return c.newInst([]byte{}), pe
     }
-    return KFPIID{slc: bytes}, err
+    le := fpiidLittleEndian(c.options(), format)
+    bytes = fpiidPermute(c.options(), bytes, le, true)
+    if !le { bytes = reverseBytes(bytes) }
+    if skip > 0 { // Signed
+        return c.FromInt(fpiidIntValue(c.options(), c.newInst(bytes).Int())), nil
+    }
+    return c.newInst(bytes), nil
+}
+
+// options returns the config of the handler that produced the FPIID
+func (id KFPIID) options() *FPIIDConfig {
+    if id.opts == nil { return &FPIIDOptions }
+    return id.opts
+}
+
+// handler returns a handler sharing the config of the one that produced the FPIID
+func (id KFPIID) handler() FPIIDCtrl {
+    return FPIIDCtrl{id.options()}
 }
 
 // -- Produce --
@@ -119,86 +201,218 @@ func (id KFPIID) Int() (res uint64) {
     return
 }
 
+// Int64 returns FPIID as signed 64-bit integer, the inverse of FromInt64
+func (id KFPIID) Int64() int64 {
+    return int64(id.Int())
+}
+
+// Encode returns string representation of FPIID in the named encoding, which
+// may be any of those DecodeAs accepts
+func (id KFPIID) Encode(name string) (string, error) {
+    enc, ok := fpiidEncoding(id.options(), name)
+    if !ok { return "", errors.New("unknown FPIID encoding " + name) }
+    if err := fpiidCheckSecret(id.options()); err != nil { return "", err }
+    if id.slc == nil || len(id.slc) == 0    { return "", nil }
+    return id.cached(name, func() string {
+        bytes := fpiidEncodingBytes(id, name)
+        bytes = fpiidPermute(id.options(), bytes, fpiidLittleEndian(id.options(), name), false)
+        if id.options().Signed { return fpiidSignedMark + enc.EncodeToString(bytes) }
+        return enc.EncodeToString(bytes)
+    }), nil
+}
+
 // B64 returns base 64 encoded string representation of FPIID
-func (id *KFPIID) B64() string {
-    if id.slc == nil || len(id.slc) == 0 { return "" }
-    if FPIIDOptions.Cache && id.b64 != "" { return id.b64 }
-    var bytes []byte;
-    copy(bytes, id.slc) 
-    if FPIIDOptions.ShortStr { bytes = fpiidTrimBytes(id) }
-    res := base64.StdEncoding.EncodeToString(bytes)
-    if !FPIIDOptions.PadB64 { res = strings.Replace(res, "=", "", -1) }
-    id.b64 = res
-    return id.b64
+func (id KFPIID) B64() string {
+    res, _ := id.Encode("b64")
+    return res
 }
 
 // B32 returns base 32 encoded string representation of FPIID
-func (id *KFPIID) B32() string {
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if FPIIDOptions.Cache && id.b32 != ""   { return id.b32 }
-    var bytes []byte;
-    copy(bytes, id.slc)
-    if FPIIDOptions.ShortStr { bytes = fpiidTrimBytes(id) }
-    res := base32.StdEncoding.EncodeToString(bytes)
-    if !FPIIDOptions.PadB32 { res = strings.Replace(res, "=", "", -1) }
-    id.b32 = res
-    return id.b32
+func (id KFPIID) B32() string {
+    res, _ := id.Encode("b32")
+    return res
 }
 
 // URL64 returns URL-safe base 64 string representation FPIID
-func (id *KFPIID) URL64() string {
-    var res string
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if FPIIDOptions.Cache && id.url64 != "" { return id.url64 }
-    if FPIIDOptions.Cache && id.b64 != "" { res = id.b64 } else { res = id.B64() }
-    id.url64 = b64ToURL64(res)
-    return id.url64
+func (id KFPIID) URL64() string {
+    res, _ := id.Encode("url64")
+    return res
 }
 
 // URL32 returns formatted, URL-safe base 32 string representation of FPIID
-func (id *KFPIID) URL32() string {
-    var res string
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if FPIIDOptions.Cache && id.url32 != "" { return id.url32 }
-    if FPIIDOptions.Cache && id.b32 != "" { res = id.b32 } else { res = id.B32() }
-    res = strings.Replace(res, "=", "", -1)
-    if FPIIDOptions.HyphURL32 { res = hyphenate(res, 4) }
-    id.url32 = res
-    return id.url32
-}
-
-// -- Decode --
-
-func (_ FPIIDCtrl) fromB64(s string, bLen, sLen int) ([]byte, error) {
-    bytes := make([]byte, bLen/8)
-    pieces := []string{url64ToB64(s)}
-    for ; len(s) < sLen; sLen-- {
-        pieces = append(pieces, "=")
+func (id KFPIID) URL32() string {
+    res, _ := id.Encode("url32")
+    return res
+}
+
+// Crockford returns Crockford base 32 representation of the FPIID's value, which
+// avoids confusable characters, with a check symbol if the CrockfordCheck option is set
+func (id KFPIID) Crockford() string {
+    res, _ := id.Encode("crockford")
+    return res
+}
+
+// B62 returns fixed-width, zero-padded base 62 representation of the FPIID's
+// 64-bit value, made only of letters and digits and sorting in numeric order
+func (id KFPIID) B62() string {
+    res, _ := id.Encode("b62")
+    return res
+}
+
+// B36 returns fixed-width, zero-padded, lower case base 36 representation of
+// the FPIID's 64-bit value, sorting in numeric order
+func (id KFPIID) B36() string {
+    res, _ := id.Encode("b36")
+    return res
+}
+
+// cached returns the string stored under key, encoding it with enc first if
+// there is none or caching is off
+func (id KFPIID) cached(key string, enc func() string) string {
+    return id.cache.get(id.options().Cache, key, enc)
+}
+
+// -- Encodings --
+
+// fpiidEncoding returns the named encoding, with the built-in ones set up
+// according to opts
+func fpiidEncoding(opts *FPIIDConfig, name string) (Encoding, bool) {
+    sort := opts.ByteOrder == FPIIDSortable
+    var enc Encoding
+    ok := true
+    switch name {
+    case "b64":
+        enc = base64Encoding{pad: opts.PadB64, sort: sort}
+    case "url64":
+        enc = base64Encoding{url: true, sort: sort}
+    case "b32":
+        enc = base32Encoding{pad: opts.PadB32, hex: sort}
+    case "url32":
+        enc = base32Encoding{hyph: opts.HyphURL32, hex: sort}
+    case "crockford": // has a check symbol of its own
+        check := opts.CrockfordCheck || opts.Checksum
+        return crockfordEncoding{check: check, require: opts.Checksum}, true
+    case "b58": // fixed width, like b62 and b36
+        enc = radixEncoding{alphabet: b58Alphabet, pad: true}
+    default:
+        enc, ok = LookupEncoding(name)
+    }
+    if ok && opts.Checksum { enc = withChecksum(enc, name, sort) }
+    return enc, ok
+}
+
+// fpiidLittleEndian reports whether the named encoding takes the FPIID's
+// little-endian bytes, as the base 64 and 32 forms do unless ByteOrder is set
+func fpiidLittleEndian(opts *FPIIDConfig, name string) bool {
+    if opts.ByteOrder != FPIIDLittleEndian { return false }
+    switch name {
+    case "b64", "url64", "b32", "url32":
+        return true
+    }
+    return false
+}
+
+// fpiidEncodingBytes returns the bytes of the FPIID the named encoding takes:
+// the full 64-bit value for the fixed-width base 62 and 36 forms and when
+// sortable, otherwise the value trimmed to 16 or 32 bits if ShortStr is set
+func fpiidEncodingBytes(id KFPIID, name string) []byte {
+    opts := id.options()
+    val := id.Int()
+    if opts.Signed { val = fpiidStrValue(opts, val) }
+    bytes := make([]byte, 8)
+    binary.LittleEndian.PutUint64(bytes, val)
+    full := fpiidFullWidth(name) || opts.ByteOrder == FPIIDSortable
+    if !full && opts.ShortStr { bytes = fpiidTrimBytes(val) }
+    if fpiidLittleEndian(opts, name) { return bytes }
+    return reverseBytes(bytes)
+}
+
+// fpiidFullWidth reports whether the named encoding always takes all 64 bits,
+// giving strings of one width whose length can't be mistaken for another's
+func fpiidFullWidth(name string) bool {
+    switch name {
+    case "b62", "b36", "b58", "crockford":
+        return true
+    }
+    return false
+}
+
+// fpiidSignedMark leads strings of the Signed option, so that any handler
+// decodes them as signed; no built-in encoding uses it
+const fpiidSignedMark = "~"
+
+// fpiidStrValue maps a signed value to the unsigned one its strings encode:
+// zig-zag encoded, so small negative values stay short, or with the sign bit
+// flipped when sortable, so negative values sort first
+func fpiidStrValue(opts *FPIIDConfig, val uint64) uint64 {
+    if opts.ByteOrder == FPIIDSortable { return val ^ 1 << 63 }
+    return zigzag(int64(val))
+}
+
+// fpiidIntValue reverses fpiidStrValue
+func fpiidIntValue(opts *FPIIDConfig, val uint64) uint64 {
+    if opts.ByteOrder == FPIIDSortable { return val ^ 1 << 63 }
+    return uint64(unzigzag(val))
+}
+
+// -- Obfuscation --
+
+// fpiidPermute applies the keyed permutation of the Obfuscate option, or its
+// inverse, to the value in b, keeping its width and byte order
+func fpiidPermute(opts *FPIIDConfig, b []byte, le, inverse bool) []byte {
+    if !opts.Obfuscate { return b }
+    if !le { b = reverseBytes(b) }
+    var v uint64
+    for i := len(b) - 1; i >= 0; i-- {
+        v = v << 8 | uint64(b[i])
+    }
+    bits := uint(len(b) * 8)
+    if inverse {
+        v = feistelDecrypt(v, bits, opts.Secret)
+    } else {
+        v = feistelEncrypt(v, bits, opts.Secret)
     }
-    s = strings.Join(pieces, "")
-    bytes, err := base64.StdEncoding.DecodeString(s)
-    return bytes, err
-}
-
-func (_ FPIIDCtrl) fromB32(s string, bLen, sLen int) ([]byte, error) {
-    bytes := make([]byte, bLen/8)
-    s = strings.Replace(s, " ", "", -1)
-    s = strings.Replace(s, "-", "", -1) 
-    s = strings.Replace(s, "=", "", -1) 
-    s = strings.ToUpper(s)
-    pieces := []string{s}
-    for ; len(s) < sLen; sLen-- {
-        pieces = append(pieces, "=")
+    res := make([]byte, len(b))
+    for i := range res {
+        res[i] = byte(v >> (8 * uint(i)))
     }
-    s = strings.Join(pieces, "")
-    bytes, err := base32.StdEncoding.DecodeString(s)
-    return bytes, err
+    if !le { res = reverseBytes(res) }
+    return res
+}
+
+// fpiidCheckSecret returns an error if obfuscation is on without a key
+func fpiidCheckSecret(opts *FPIIDConfig) error {
+    if opts.Obfuscate && len(opts.Secret) == 0 {
+        return errors.New("FPIID obfuscation needs a secret")
+    }
+    return nil
 }
 
 // -- Helpers --
 
-func fpiidTrimBytes(id *KFPIID) []byte {
-    val := id.Int()
+// zigzag maps signed to unsigned integers so that values close to zero, of
+// either sign, stay small: 0, -1, 1, -2... become 0, 1, 2, 3...
+func zigzag(v int64) uint64 {
+    return uint64(v << 1) ^ uint64(v >> 63)
+}
+
+// unzigzag reverses zigzag
+func unzigzag(v uint64) int64 {
+    return int64(v >> 1) ^ -int64(v & 1)
+}
+
+// reverseBytes returns a reversed copy of b, swapping its byte order
+func reverseBytes(b []byte) []byte {
+    res := make([]byte, len(b))
+    for i := range b {
+        res[i] = b[len(b)-1-i]
+    }
+    return res
+}
+
+// fpiidTrimBytes returns val as little-endian uint16, 32 or 64, whichever
+// is the smallest it fits
+func fpiidTrimBytes(val uint64) []byte {
     switch {
     case (val <= maxVal16):
         tmp := make([]byte, 2)
@@ -209,5 +423,7 @@ func fpiidTrimBytes(id *KFPIID) []byte {
         binary.LittleEndian.PutUint32(tmp, uint32(val))
         return tmp
     }
-    return id.slc
+    tmp := make([]byte, 8)
+    binary.LittleEndian.PutUint64(tmp, val)
+    return tmp
 }
\ No newline at end of file
@@ -0,0 +1,155 @@
+package kee
+
+import (
+    "errors"
+    "net/url"
+    "strconv"
+)
+
+// ErrPasswordMismatch is returned when a one time password matches none of
+// those it was checked against
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// KHOTP type represents a secret capable of producing counter-based one time passwords. (RFC 4226)
+// It is exported only for reference and should be instantiated through its handler's methods.
+type KHOTP struct {
+    slc []byte
+    b32 string
+    opts *HOTPConfig
+    alg OTPAlgorithm    // Zero values fall back on opts
+    digits int
+}
+
+// HOTPConfig is the struct for HOTPOptions. It should only be used if
+// another handler with a different set of options is being created.
+type HOTPConfig struct {
+    B32Blocks int
+    HyphB32, WholeSecret bool
+    Algorithm OTPAlgorithm
+    Digits int
+}
+
+// HOTPOptions defines the configuration used by the `kee.HOTP` handler.
+// Options can also be changed through `kee.HOTP.Options`.
+var HOTPOptions = HOTPConfig {
+    B32Blocks: 8,           // Secret length (change will invalidate stored pws)
+    HyphB32: true,          // Hyphenate base 32 encoded secrets
+    WholeSecret: false,     // Keep secrets whole, ignoring B32Blocks
+    Algorithm: OTPSHA1,     // HMAC hash function: OTPSHA1, OTPSHA256 or OTPSHA512
+    Digits: 6,              // Password length, from 6 to 10
+}
+
+// HOTPCtrl is a struct for the HOTP handler.
+// Unless another handler with different options is needed simply use instance `kee.HOTP`.
+type HOTPCtrl struct {
+    Options         *HOTPConfig
+}
+
+// options returns the handler's config, falling back on HOTPOptions
+func (c HOTPCtrl) options() *HOTPConfig {
+    if c.Options == nil { return &HOTPOptions }
+    return c.Options
+}
+
+// New generates a new secret and returns KHOTP instance
+func (c HOTPCtrl) New() KHOTP {
+    bytes := make([]byte, 32)
+    randomBits(bytes)
+    return KHOTP{slc: bytes, opts: c.options()}
+}
+
+// Set loads an existing secret and returns KHOTP instance. Secrets shorter
+// than 32 bytes are zero-padded; longer ones are kept whole.
+func (c HOTPCtrl) Set(bytes []byte) KHOTP {
+    return KHOTP{slc: otpSetBytes(bytes), opts: c.options()}
+}
+
+// Decode takes base 32 encoded string of secret and returns KHOTP instance
+func (c HOTPCtrl) Decode(s string) (KHOTP, error) {
+    s, err := otpDecode(s, otpBlocks(c.options().B32Blocks, c.options().WholeSecret))
+    if err != nil { return KHOTP{}, err }
+    return KHOTP{b32: s, opts: c.options()}, nil
+}
+
+// options returns the config of the handler that produced the secret
+func (id *KHOTP) options() *HOTPConfig {
+    if id.opts == nil { return &HOTPOptions }
+    return id.opts
+}
+
+// String is alias for B32()
+func (id *KHOTP) String() string {
+    return id.B32()
+}
+
+// Slc returns secret as slice. This method is only meant to be used immediately after
+// generating or loading a secret to store it somewhere permanently.
+func (id *KHOTP) Slc() []byte {
+    return id.slc
+}
+
+// B32 returns base 32 encoded string representation of secret
+func (id *KHOTP) B32() string {
+    res := id.secret()
+    if id.options().HyphB32 { res = hyphenate(res, 4) }
+    return res
+}
+
+// Algorithm returns the HMAC hash function of the secret's passwords
+func (id *KHOTP) Algorithm() OTPAlgorithm {
+    if id.alg != "" { return id.alg }
+    if id.options().Algorithm != "" { return id.options().Algorithm }
+    return OTPSHA1
+}
+
+// Digits returns the number of digits of the secret's passwords
+func (id *KHOTP) Digits() int {
+    if id.digits != 0 { return id.digits }
+    if id.options().Digits != 0 { return id.options().Digits }
+    return 6
+}
+
+// URI returns Uniform Resource Identifier with secret and the counter value
+// of the next password for QR code generation
+func (id *KHOTP) URI(acct, issuer string, counter uint64) string {
+    acct = otpEscapeLabel(acct)
+    issuer = url.QueryEscape(issuer)
+    return "otpauth://hotp/"+acct+"?secret="+id.secret()+"&issuer="+issuer+
+        "&algorithm="+string(id.Algorithm())+
+        "&digits="+strconv.Itoa(id.Digits())+
+        "&counter="+strconv.FormatUint(counter, 10)
+}
+
+// secret returns the unhyphenated base 32 secret, cut to the configured
+// number of blocks if it was generated or set from bytes
+func (id *KHOTP) secret() string {
+    if id.b32 == "" { id.b32 = otpSecret(id.slc, otpBlocks(id.options().B32Blocks, id.options().WholeSecret)) }
+    return id.b32
+}
+
+// Generate returns the password for counter value counter
+func (id *KHOTP) Generate(counter uint64) (uint32, error) {
+    key, err := otpKey(id.secret())
+    if err != nil { return 0, err }
+    newHash, err := otpHash(id.Algorithm(), id.Digits())
+    if err != nil { return 0, err }
+    return otpGetPassword(newHash, key, totpToBytes(int64(counter)), id.Digits()), nil
+}
+
+// Verify checks code against the passwords for counter and the window
+// counter values after it. On a match it returns the counter value to expect
+// next, one past the matching one, which the caller must store to keep in
+// sync with the client; otherwise it returns counter and ErrPasswordMismatch.
+func (id *KHOTP) Verify(code uint32, counter uint64, window int) (uint64, error) {
+    key, err := otpKey(id.secret())
+    if err != nil { return counter, err }
+    newHash, err := otpHash(id.Algorithm(), id.Digits())
+    if err != nil { return counter, err }
+    for i := 0; i <= window; i++ {
+        next := counter + uint64(i)
+        if otpGetPassword(newHash, key, totpToBytes(int64(next)), id.Digits()) == code {
+            return next + 1, nil
+        }
+    }
+    return counter, ErrPasswordMismatch
+}
@@ -20,6 +20,9 @@ var (
 	// TOTP handler for One-time Time Based Passwords
 	TOTP TOTPCtrl
 
+	// HOTP handler for One-time Counter Based Passwords
+	HOTP HOTPCtrl
+
 	// JUMBLE handler for word-jumble identifiers
 	JUMBLE JUMCtrl
 )
@@ -37,7 +40,8 @@ func init() {
 	}
 	FPIID = FPIIDCtrl{&FPIIDOptions}
 	APIID = APIIDCtrl{&APIIDOptions}
-	TOTP = TOTPCtrl{&TOTPOptions}
+	TOTP = TOTPCtrl{Options: &TOTPOptions}
+	HOTP = HOTPCtrl{&HOTPOptions}
 	JUMBLE = JUMCtrl{
 		phrase: []jumWord{
 			&jumAdjectives{},
@@ -90,7 +90,7 @@ func (_ UUIDCtrl) setNodeID(id []byte) bool {
 }
 
 // NodeID returns the 6 byte node id encoded in UUID.  It returns nil if UUID is
-// not valid.  The NodeID is only well defined for version 1 and 2 UUIDs.
+// not valid.  The NodeID is only well defined for version 1, 2 and 6 UUIDs.
 func (id KUUID) NodeID() []byte {
 	bytes := id.slc
 	if len(bytes) != 16 {
@@ -0,0 +1,52 @@
+package kee
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "sync"
+)
+
+// ErrPasswordReused is returned when a one time password matches, but one of
+// the same or a later time step was already accepted for the secret
+var ErrPasswordReused = errors.New("password already used")
+
+// UsedCodeStore remembers the last time step a password was accepted for, per
+// secret, so that KTOTP.Verify accepts every password only once. Secrets are
+// identified by a hash of their key, never the key itself. Implementations
+// shared between servers, e.g. on top of a database, must be safe for
+// concurrent use.
+type UsedCodeStore interface {
+    // MarkUsed records step as the last one accepted for key and returns
+    // true, unless it is not after the last one recorded, in which case it
+    // returns false and records nothing. Both must happen atomically.
+    MarkUsed(key string, step int64) (bool, error)
+}
+
+// MemoryCodeStore is a UsedCodeStore kept in memory, for a single process.
+// It holds one entry for every secret ever verified. Use NewMemoryCodeStore
+// to instantiate.
+type MemoryCodeStore struct {
+    mu sync.Mutex
+    steps map[string]int64
+}
+
+// NewMemoryCodeStore returns an empty MemoryCodeStore
+func NewMemoryCodeStore() *MemoryCodeStore {
+    return &MemoryCodeStore{steps: make(map[string]int64)}
+}
+
+// MarkUsed implements UsedCodeStore
+func (s *MemoryCodeStore) MarkUsed(key string, step int64) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if last, ok := s.steps[key]; ok && step <= last { return false, nil }
+    s.steps[key] = step
+    return true, nil
+}
+
+// otpStoreKey returns the key a secret is stored under in a UsedCodeStore
+func otpStoreKey(key []byte) string {
+    sum := sha256.Sum256(key)
+    return hex.EncodeToString(sum[:])
+}
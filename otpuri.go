@@ -0,0 +1,99 @@
+package kee
+
+import (
+    "errors"
+    "net/url"
+    "strconv"
+    "strings"
+)
+
+// OTPAccount holds what an otpauth:// URI says about the account its secret
+// belongs to
+type OTPAccount struct {
+    Type string     // "totp" or "hotp"
+    Account string  // Account name, e.g. an email address
+    Issuer string   // Provider of the account, if given
+    Counter uint64  // Counter value of the next password, for "hotp" only
+}
+
+// otpURI is an otpauth:// URI taken apart, with the defaults of the Key Uri
+// Format filled in for missing parameters
+type otpURI struct {
+    acct OTPAccount
+    b32 string
+    alg OTPAlgorithm
+    digits, period int
+}
+
+// ParseURI takes an otpauth:// URI, as made by URI or exported by
+// authenticator apps, and returns KTOTP instance with the URI's secret,
+// algorithm, digits and period, along with the account it is for. HOTP URIs
+// are accepted too, for their account and counter; see HOTPCtrl.ParseURI.
+func (c TOTPCtrl) ParseURI(uri string) (KTOTP, OTPAccount, error) {
+    res, err := parseOTPURI(uri)
+    if err != nil { return KTOTP{}, OTPAccount{}, err }
+    return KTOTP{b32: res.b32, opts: c.options(), clock: c.Clock,
+        alg: res.alg, digits: res.digits, period: res.period}, res.acct, nil
+}
+
+// ParseURI takes an otpauth:// URI, as made by URI or exported by
+// authenticator apps, and returns KHOTP instance with the URI's secret,
+// algorithm and digits, along with the account it is for and its counter.
+// TOTP URIs are accepted too; see TOTPCtrl.ParseURI.
+func (c HOTPCtrl) ParseURI(uri string) (KHOTP, OTPAccount, error) {
+    res, err := parseOTPURI(uri)
+    if err != nil { return KHOTP{}, OTPAccount{}, err }
+    return KHOTP{b32: res.b32, opts: c.options(),
+        alg: res.alg, digits: res.digits}, res.acct, nil
+}
+
+// otpEscapeLabel escapes an account name for the label of an otpauth:// URI:
+// as a path, so "+" stays itself, and with colons escaped too, as the first
+// one would otherwise end the issuer prefix
+func otpEscapeLabel(acct string) string {
+    return strings.Replace(url.PathEscape(acct), ":", "%3A", -1)
+}
+
+// parseOTPURI parses an otpauth:// URI of either type
+func parseOTPURI(uri string) (otpURI, error) {
+    res := otpURI{alg: OTPSHA1, digits: 6, period: 30}
+    u, err := url.Parse(uri)
+    if err != nil { return res, err }
+    if u.Scheme != "otpauth" { return res, errors.New("not an otpauth URI") }
+    res.acct.Type = strings.ToLower(u.Host)
+    if res.acct.Type != "totp" && res.acct.Type != "hotp" {
+        return res, errors.New("unknown one time password type " + u.Host)
+    }
+
+    // Label is "account" or "issuer:account", escaped as a path, so "+" is
+    // itself rather than a space; split before unescaping, as escaped colons
+    // belong to the account
+    label := strings.TrimPrefix(u.EscapedPath(), "/")
+    issuer := ""
+    if i := strings.Index(label, ":"); i >= 0 { issuer, label = label[:i], label[i+1:] }
+    if res.acct.Issuer, err = url.PathUnescape(issuer); err != nil { return res, err }
+    if res.acct.Account, err = url.PathUnescape(label); err != nil { return res, err }
+    if issuer != "" { res.acct.Account = strings.TrimLeft(res.acct.Account, " ") }
+
+    q := u.Query()
+    if issuer := q.Get("issuer"); issuer != "" { res.acct.Issuer = issuer }
+    if q.Get("secret") == "" { return res, errors.New("otpauth URI has no secret") }
+    if res.b32, err = otpDecode(q.Get("secret"), 0); err != nil { return res, err }
+    if _, err = otpKey(res.b32); err != nil { return res, errors.New("otpauth URI has malformed secret") }
+    if alg := q.Get("algorithm"); alg != "" { res.alg = OTPAlgorithm(strings.ToUpper(alg)) }
+    if s := q.Get("digits"); s != "" {
+        if res.digits, err = strconv.Atoi(s); err != nil { return res, errors.New("otpauth URI has malformed digits") }
+    }
+    if _, err = otpHash(res.alg, res.digits); err != nil { return res, err }
+    if s := q.Get("period"); s != "" {
+        if res.period, err = strconv.Atoi(s); err != nil || res.period <= 0 {
+            return res, errors.New("otpauth URI has malformed period")
+        }
+    }
+    if res.acct.Type == "hotp" {
+        if res.acct.Counter, err = strconv.ParseUint(q.Get("counter"), 10, 64); err != nil {
+            return res, errors.New("otpauth URI has missing or malformed counter")
+        }
+    }
+    return res, nil
+}
@@ -0,0 +1,95 @@
+package kee
+
+import (
+    "errors"
+    "sync"
+    "time"
+)
+
+// Snowflake-style FPIIDs are laid out, from the most significant bit, as
+// milliseconds since Epoch, worker ID and a per-millisecond sequence, so that
+// they sort by time of creation. The top bit is left clear to keep them
+// positive as signed 64-bit integers.
+
+// snowflakeState is the last millisecond and sequence handed out for a config
+type snowflakeState struct {
+    last, seq uint64
+    started bool
+}
+
+var snowflakes = struct {
+    sync.Mutex
+    m map[*FPIIDConfig]*snowflakeState
+}{m: make(map[*FPIIDConfig]*snowflakeState)}
+
+// New returns a new, unique, time-ordered FPIID laid out according to the
+// Epoch, TimeBits, WorkerBits, SeqBits and WorkerID options. It is safe for
+// concurrent use; handlers sharing a config share their sequence.
+func (c FPIIDCtrl) New() (KFPIID, error) {
+    opts := c.options()
+    if err := snowflakeCheck(opts); err != nil { return KFPIID{}, err }
+    since := timeNow().Sub(opts.Epoch)
+    if since < 0 {
+        return KFPIID{}, errors.New("clock is set before FPIID epoch")
+    }
+    snowflakes.Lock()
+    st, ok := snowflakes.m[opts]
+    if !ok {
+        st = &snowflakeState{}
+        snowflakes.m[opts] = st
+    }
+    ms, seq := st.next(uint64(since / time.Millisecond), opts.SeqBits)
+    snowflakes.Unlock()
+    if ms >> opts.TimeBits != 0 {
+        return KFPIID{}, errors.New("FPIID timestamp exceeds its bits")
+    }
+    id := ms << (opts.WorkerBits + opts.SeqBits) |
+        opts.WorkerID << opts.SeqBits | seq
+    return c.FromInt(id), nil
+}
+
+// next returns the millisecond and sequence for the next FPIID. Within one
+// millisecond, or if the clock has stalled or gone backwards, the sequence is
+// incremented; when it runs out the millisecond is advanced instead, so IDs
+// never repeat or go out of order.
+func (st *snowflakeState) next(now uint64, seqBits uint) (uint64, uint64) {
+    if now > st.last || !st.started {
+        st.last, st.seq, st.started = now, 0, true
+        return st.last, st.seq
+    }
+    st.seq++
+    if st.seq >> seqBits != 0 {
+        st.last, st.seq = st.last+1, 0
+    }
+    return st.last, st.seq
+}
+
+// snowflakeCheck returns an error if the layout in opts does not fit 63 bits
+func snowflakeCheck(opts *FPIIDConfig) error {
+    if opts.TimeBits == 0 || opts.TimeBits + opts.WorkerBits + opts.SeqBits > 63 {
+        return errors.New("FPIID time, worker and sequence bits must fit in 63")
+    }
+    if opts.WorkerID >> opts.WorkerBits != 0 {
+        return errors.New("FPIID worker ID exceeds its bits")
+    }
+    return nil
+}
+
+// Timestamp returns the creation time of an FPIID made by New, to the
+// millisecond; the FPIID is read with the layout of its handler's options
+func (id KFPIID) Timestamp() time.Time {
+    opts := id.options()
+    ms := id.Int() >> (opts.WorkerBits + opts.SeqBits) & (1 << opts.TimeBits - 1)
+    return opts.Epoch.Add(time.Duration(ms) * time.Millisecond)
+}
+
+// WorkerID returns the worker ID of an FPIID made by New
+func (id KFPIID) WorkerID() uint64 {
+    opts := id.options()
+    return id.Int() >> opts.SeqBits & (1 << opts.WorkerBits - 1)
+}
+
+// Sequence returns the per-millisecond sequence of an FPIID made by New
+func (id KFPIID) Sequence() uint64 {
+    return id.Int() & (1 << id.options().SeqBits - 1)
+}
@@ -0,0 +1,183 @@
+package kee
+
+import (
+    "database/sql/driver"
+    "errors"
+    "fmt"
+    "math/big"
+    "strconv"
+)
+
+// -- UUID --
+
+// Value implements driver.Valuer; UUIDs are stored as 16 bytes, or as
+// canonical hex strings if the SQLText option is set
+func (id KUUID) Value() (driver.Value, error) {
+    if len(id.slc) == 0 { return nil, nil }
+    if id.options().SQLText { return id.Hex(), nil }
+    return id.MarshalBinary()
+}
+
+// Scan implements sql.Scanner; accepts 16 raw bytes or any string the
+// UUID handler's Decode method accepts
+func (id *KUUID) Scan(src interface{}) error {
+    switch src := src.(type) {
+    case []byte:
+        if len(src) == 16 { return id.UnmarshalBinary(src) }
+        return id.UnmarshalText(src)
+    case string:
+        return id.UnmarshalText([]byte(src))
+    case nil:
+        return errors.New("cannot scan NULL into KUUID; use NullUUID")
+    }
+    return fmt.Errorf("cannot scan %T into KUUID", src)
+}
+
+// NullUUID represents a UUID that may be NULL. It implements sql.Scanner and
+// driver.Valuer so it can be used as a scan destination or query argument.
+type NullUUID struct {
+    UUID KUUID
+    Valid bool      // Valid is true if UUID is not NULL
+}
+
+// Value implements driver.Valuer
+func (n NullUUID) Value() (driver.Value, error) {
+    if !n.Valid { return nil, nil }
+    return n.UUID.Value()
+}
+
+// Scan implements sql.Scanner
+func (n *NullUUID) Scan(src interface{}) error {
+    if src == nil {
+        n.UUID, n.Valid = KUUID{}, false
+        return nil
+    }
+    n.Valid = true
+    return n.UUID.Scan(src)
+}
+
+// -- FPIID --
+
+// Value implements driver.Valuer; FPIIDs are stored as BIGINT. Values above
+// the signed 64-bit maximum wrap around to negative but scan back unchanged.
+func (id KFPIID) Value() (driver.Value, error) {
+    if len(id.slc) == 0 { return nil, nil }
+    return int64(id.Int()), nil
+}
+
+// Scan implements sql.Scanner; accepts integers, decimal strings or any
+// string the FPIID handler's Decode method accepts
+func (id *KFPIID) Scan(src interface{}) error {
+    switch src := src.(type) {
+    case int64:
+        *id = id.handler().FromInt(uint64(src))
+        return nil
+    case []byte:
+        return id.scanString(string(src))
+    case string:
+        return id.scanString(src)
+    case nil:
+        return errors.New("cannot scan NULL into KFPIID; use NullFPIID")
+    }
+    return fmt.Errorf("cannot scan %T into KFPIID", src)
+}
+
+func (id *KFPIID) scanString(s string) error {
+    if v, err := strconv.ParseUint(s, 10, 64); err == nil {
+        *id = id.handler().FromInt(v)
+        return nil
+    }
+    if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+        *id = id.handler().FromInt(uint64(v))
+        return nil
+    }
+    res, err := id.handler().Decode(s)
+    if err != nil { return err }
+    *id = res
+    return nil
+}
+
+// NullFPIID represents an FPIID that may be NULL. It implements sql.Scanner and
+// driver.Valuer so it can be used as a scan destination or query argument.
+type NullFPIID struct {
+    FPIID KFPIID
+    Valid bool      // Valid is true if FPIID is not NULL
+}
+
+// Value implements driver.Valuer
+func (n NullFPIID) Value() (driver.Value, error) {
+    if !n.Valid { return nil, nil }
+    return n.FPIID.Value()
+}
+
+// Scan implements sql.Scanner
+func (n *NullFPIID) Scan(src interface{}) error {
+    if src == nil {
+        n.FPIID, n.Valid = KFPIID{}, false
+        return nil
+    }
+    n.Valid = true
+    return n.FPIID.Scan(src)
+}
+
+// -- APIID --
+
+// Value implements driver.Valuer; APIIDs are stored as decimal strings, which
+// suit both NUMERIC and text columns. Only the zero KAPIID{} is stored as NULL;
+// an APIID of 0 is stored as "0".
+func (id KAPIID) Value() (driver.Value, error) {
+    if id.bigInt == nil { return nil, nil }
+    return id.bigInt.String(), nil
+}
+
+// Scan implements sql.Scanner; accepts non-negative integers and decimal strings
+func (id *KAPIID) Scan(src interface{}) error {
+    switch src := src.(type) {
+    case int64:
+        return id.scanBigInt(big.NewInt(src))
+    case []byte:
+        return id.scanString(string(src))
+    case string:
+        return id.scanString(src)
+    case nil:
+        return errors.New("cannot scan NULL into KAPIID; use NullAPIID")
+    }
+    return fmt.Errorf("cannot scan %T into KAPIID", src)
+}
+
+func (id *KAPIID) scanString(s string) error {
+    i, ok := new(big.Int).SetString(s, 10)
+    if !ok { return fmt.Errorf("cannot scan %q into KAPIID", s) }
+    return id.scanBigInt(i)
+}
+
+// scanBigInt sets the APIID to i, which must not be negative: FromBigInt would
+// take its absolute value, a different ID
+func (id *KAPIID) scanBigInt(i *big.Int) error {
+    if i.Sign() < 0 { return fmt.Errorf("cannot scan negative %s into KAPIID", i) }
+    *id = id.handler().FromBigInt(i)
+    return nil
+}
+
+// NullAPIID represents an APIID that may be NULL. It implements sql.Scanner and
+// driver.Valuer so it can be used as a scan destination or query argument.
+type NullAPIID struct {
+    APIID KAPIID
+    Valid bool      // Valid is true if APIID is not NULL
+}
+
+// Value implements driver.Valuer
+func (n NullAPIID) Value() (driver.Value, error) {
+    if !n.Valid { return nil, nil }
+    return n.APIID.Value()
+}
+
+// Scan implements sql.Scanner
+func (n *NullAPIID) Scan(src interface{}) error {
+    if src == nil {
+        n.APIID, n.Valid = KAPIID{}, false
+        return nil
+    }
+    n.Valid = true
+    return n.APIID.Scan(src)
+}
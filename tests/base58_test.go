@@ -0,0 +1,77 @@
+package main
+
+import (
+    "encoding/hex"
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestBase58(t *testing.T) {
+
+    Convey("When APIIDs use the Bitcoin alphabet", t, func() {
+        opts := kee.APIIDOptions
+        opts.Cache, opts.B58Alphabet = false, kee.Base58Bitcoin
+        ctrl := kee.APIIDCtrl{Options: &opts}
+
+        Convey("Strings should match other Bitcoin base 58 encoders", func() {
+            So(ctrl.Set([]byte("Hello World!")).B58(), ShouldEqual, "2NEpo7TZRRrLZSi2U")
+            So(ctrl.Set([]byte{0, 0, 0x28, 0x7f, 0xb4, 0xcd}).B58(), ShouldEqual, "11233QC4")
+        })
+
+        Convey("Leading zero bytes should round-trip", func() {
+            for _, slc := range [][]byte{{0}, {0, 0, 0}, {0, 1}, {0, 0, 255, 0}} {
+                s := ctrl.Set(slc).B58()
+                res, err := ctrl.Decode(s)
+                So(err, ShouldBeNil)
+                So(res.Slc(), ShouldResemble, slc)
+            }
+        })
+    })
+
+    Convey("When Base58Check is on", t, func() {
+        opts := kee.APIIDOptions
+        opts.Cache, opts.B58Alphabet, opts.B58Check = false, kee.Base58Bitcoin, true
+        ctrl := kee.APIIDCtrl{Options: &opts}
+        payload, _ := hex.DecodeString("00010966776006953D5567439E5E39F86A0D273BEE")
+
+        Convey("A versioned payload should give its Bitcoin address", func() {
+            So(ctrl.Set(payload).B58(), ShouldEqual, "16UwLL9Risc3QfPqBUvKofHmBQ7wMtjvM")
+            res, err := ctrl.Decode("16UwLL9Risc3QfPqBUvKofHmBQ7wMtjvM")
+            So(err, ShouldBeNil)
+            So(res.Slc(), ShouldResemble, payload)
+        })
+
+        Convey("A corrupted string should fail its checksum", func() {
+            _, err := ctrl.Decode("16UwLL9Risc3QfPqBUvKofHmBQ7wMtjvN")
+            So(asParseError(err).Reason, ShouldEqual, kee.ParseBadCheck)
+            _, err = ctrl.Decode("1")
+            So(asParseError(err).Reason, ShouldEqual, kee.ParseBadLength)
+        })
+    })
+
+    Convey("When other alphabets are set", t, func() {
+        opts := kee.APIIDOptions
+        opts.Cache = false
+        ctrl := kee.APIIDCtrl{Options: &opts}
+
+        Convey("The default should be unchanged and each should round-trip", func() {
+            So(kee.APIID.FromInt(185999660).B58(), ShouldEqual, "hridG")
+            for _, alphabet := range []string{kee.Base58Flickr, kee.Base58Bitcoin, kee.Base58Ripple} {
+                opts.B58Alphabet = alphabet
+                id := ctrl.FromString("654654654654654654654654")
+                res, err := ctrl.Decode(id.B58())
+                So(err, ShouldBeNil)
+                So(res.BigInt().String(), ShouldEqual, "654654654654654654654654")
+            }
+        })
+
+        Convey("An unusable alphabet should return an error", func() {
+            opts.B58Alphabet = "0123456789"
+            _, err := ctrl.FromInt(42).Encode("b58")
+            So(err, ShouldNotBeNil)
+            _, err = ctrl.Decode("42")
+            So(err, ShouldNotBeNil)
+        })
+    })
+}
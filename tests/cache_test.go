@@ -0,0 +1,65 @@
+package main
+
+import (
+    "sync"
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+// Run with `go test -race` to check the encoding caches for data races
+func TestCacheConcurrency(t *testing.T) {
+    kee.UUID.Options.Cache = true
+    kee.FPIID.Options.Cache = true
+    kee.APIID.Options.Cache = true
+
+    Convey("When one ID of each type is encoded from many goroutines", t, func() {
+        uid := kee.UUID.New()
+        fid := kee.FPIID.FromInt(555555555555555)
+        aid := kee.APIID.FromString("654654654654654654654654")
+        want := []string{
+            uid.Hex(), uid.A85(), uid.B64(), uid.B32(), uid.URN(), uid.URL64(), uid.URL32(),
+            fid.B64(), fid.B32(), fid.URL64(), fid.URL32(),
+            aid.B58(),
+        }
+
+        var wg sync.WaitGroup
+        results := make([][]string, 32)
+        for i := range results {
+            wg.Add(1)
+            go func(i int) {
+                defer wg.Done()
+                results[i] = []string{
+                    uid.Hex(), uid.A85(), uid.B64(), uid.B32(), uid.URN(), uid.URL64(), uid.URL32(),
+                    fid.B64(), fid.B32(), fid.URL64(), fid.URL32(),
+                    aid.B58(),
+                }
+            }(i)
+        }
+        wg.Wait()
+
+        Convey("Every goroutine should get the same strings", func() {
+            for _, res := range results {
+                So(res, ShouldResemble, want)
+            }
+        })
+
+    })
+
+    Convey("When caching is on and options change after encoding", t, func() {
+        fid := kee.FPIID.FromInt(555555555555555)
+        first := fid.URL32()
+        copied := fid
+        kee.FPIID.Options.HyphURL32 = false
+        second := copied.URL32()
+        kee.FPIID.Options.HyphURL32 = true
+
+        Convey("Copies should share the cached string", func() {
+            So(first, ShouldEqual, "4O4I-UW2G-7EAQ-A")
+            So(second, ShouldEqual, first)
+        })
+
+    })
+
+    kee.UUID.Options.Cache = false
+}
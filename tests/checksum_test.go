@@ -0,0 +1,99 @@
+package main
+
+import (
+    "strings"
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+// typos returns every string made by replacing one character of s, other than
+// a hyphen, with another from alphabet
+func typos(s, alphabet string) (res []string) {
+    for i := 0; i < len(s); i++ {
+        if s[i] == '-' { continue }
+        for j := 0; j < len(alphabet); j++ {
+            if alphabet[j] == s[i] { continue }
+            res = append(res, s[:i] + string(alphabet[j]) + s[i+1:])
+        }
+    }
+    return
+}
+
+func TestChecksum(t *testing.T) {
+
+    Convey("When APIIDs are checksummed", t, func() {
+        opts := kee.APIIDOptions
+        opts.Cache, opts.Checksum = false, true
+        ctrl := kee.APIIDCtrl{Options: &opts}
+        id := ctrl.FromString("654654654654654654654654")
+
+        Convey("Strings should gain one character and decode", func() {
+            plain := kee.APIID.FromString("654654654654654654654654")
+            So(id.B58(), ShouldStartWith, plain.B58())
+            So(len(id.B58()), ShouldEqual, len(plain.B58()) + 1)
+            res, err := ctrl.Decode(id.B58())
+            So(err, ShouldBeNil)
+            So(res.BigInt().String(), ShouldEqual, "654654654654654654654654")
+        })
+
+        Convey("Any single typo should be rejected", func() {
+            alphabet := "123456789abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+            missed := 0
+            for _, s := range typos(id.B58(), alphabet) {
+                if _, err := ctrl.Decode(s); err == nil { missed++ }
+            }
+            So(missed, ShouldEqual, 0)
+        })
+
+        Convey("A bad check character should be reported as such", func() {
+            s := id.B62()
+            last := "0"
+            if strings.HasSuffix(s, "0") { last = "1" }
+            _, err := ctrl.DecodeAs("b62", s[:len(s)-1] + last)
+            e := asParseError(err)
+            So(e.Reason, ShouldEqual, kee.ParseBadCheck)
+            So(e.Offset, ShouldEqual, len(s) - 1)
+        })
+    })
+
+    Convey("When FPIIDs are checksummed", t, func() {
+        opts := kee.FPIIDOptions
+        opts.Cache, opts.Checksum = false, true
+        ctrl := kee.FPIIDCtrl{Options: &opts}
+
+        Convey("Every encoding and width should round-trip through Decode", func() {
+            for _, val := range []uint64{42, 1 << 20, 555555555555555} {
+                id := ctrl.FromInt(val)
+                for _, name := range []string{"b64", "url64", "b32", "url32", "crockford", "b62", "b36"} {
+                    s, _ := id.Encode(name)
+                    So(s, ShouldNotContainSubstring, "=")
+                    res, err := ctrl.DecodeAs(name, s)
+                    So(err, ShouldBeNil)
+                    So(res.Int(), ShouldEqual, val)
+                }
+                res, err := ctrl.Decode(id.URL32())
+                So(err, ShouldBeNil)
+                So(res.Int(), ShouldEqual, val)
+                res, err = ctrl.Decode(id.URL64())
+                So(err, ShouldBeNil)
+                So(res.Int(), ShouldEqual, val)
+            }
+        })
+
+        Convey("Any single typo in URL32 should be rejected", func() {
+            s := ctrl.FromInt(555555555555555).URL32()
+            missed := 0
+            for _, typo := range typos(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567") {
+                if _, err := ctrl.DecodeAs("url32", typo); err == nil { missed++ }
+            }
+            So(missed, ShouldEqual, 0)
+        })
+
+        Convey("Crockford strings should need their check symbol", func() {
+            s := ctrl.FromInt(555555555555555).Crockford()
+            _, err := ctrl.DecodeAs("crockford", s[:len(s)-1])
+            So(asParseError(err).Reason, ShouldEqual, kee.ParseBadCheck)
+        })
+    })
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+    "math/rand"
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestCrockford(t *testing.T) {
+    kee.UUID.Options.Cache = false
+    kee.FPIID.Options.Cache = false
+
+    Convey("When a UUID is encoded in Crockford base 32", t, func() {
+        testVal := [16]byte{23, 22, 217, 229, 211, 95, 75, 134, 139, 156, 156, 34, 97, 225, 43, 143}
+        id := kee.UUID.Set(testVal)
+
+        Convey("It should match the expected value, with check symbol", func() {
+            So(id.Crockford(), ShouldEqual, "0Q2VCYBMTZ9E38Q74W49GY2AWFM")
+        })
+
+        Convey("It should drop the check symbol if CrockfordCheck is off", func() {
+            kee.UUID.Options.CrockfordCheck = false
+            So(id.Crockford(), ShouldEqual, "0Q2VCYBMTZ9E38Q74W49GY2AWF")
+            kee.UUID.Options.CrockfordCheck = true
+        })
+
+        Convey("Decoding should normalize case, hyphens and confusables", func() {
+            for _, s := range []string{
+                "0Q2VCYBMTZ9E38Q74W49GY2AWFM",
+                "oq2v-cybm-tz9e-38q7-4w49-gy2a-wfm",
+                "OQ2VCYBMTZ9E38Q74W49GY2AWFM"} {
+                res, err := kee.UUID.Decode(s)
+                So(err, ShouldBeNil)
+                So(res.Arr(), ShouldEqual, testVal)
+            }
+        })
+
+        Convey("Strings without check symbol should need DecodeAs", func() {
+            res, err := kee.UUID.DecodeAs("crockford", "oq2v-cybm-tz9e-38q7-4w49-gy2a-wf")
+            So(err, ShouldBeNil)
+            So(res.Arr(), ShouldEqual, testVal)
+            _, err = kee.UUID.Decode("0Q2VCYBMTZ9E38Q74W49GY2AWF")
+            So(err, ShouldNotBeNil)
+        })
+
+        Convey("Decoding should reject a wrong check symbol", func() {
+            _, err := kee.UUID.Decode("0Q2VCYBMTZ9E38Q74W49GY2AWFN")
+            So(err, ShouldNotBeNil)
+        })
+
+        Convey("Standard base 32 should still decode as before", func() {
+            res, err := kee.UUID.Decode("C4LN-TZOT-L5FY-NC44-TQRG-DYJL-R4")
+            So(err, ShouldBeNil)
+            So(res.Arr(), ShouldEqual, testVal)
+        })
+
+    })
+
+    Convey("When an FPIID is encoded in Crockford base 32", t, func() {
+        id := kee.FPIID.FromInt(555555555555555)
+
+        Convey("It should match the expected value, always 64 bits wide", func() {
+            So(id.Crockford(), ShouldEqual, "000FS8SDRNE730")
+            So(kee.FPIID.FromInt(12345).Crockford(), ShouldEqual, "0000000000C1SR")
+        })
+
+        Convey("Decoding should restore its value", func() {
+            for _, s := range []string{"000FS8SDRNE730", "0OOF-S8SD-RNE7-30"} {
+                res, err := kee.FPIID.Decode(s)
+                So(err, ShouldBeNil)
+                So(res.Int(), ShouldEqual, uint64(555555555555555))
+            }
+            res, err := kee.FPIID.Decode("0000000000c1sr")
+            So(err, ShouldBeNil)
+            So(res.Int(), ShouldEqual, uint64(12345))
+        })
+
+        Convey("Short strings and ones without check symbol should need DecodeAs", func() {
+            for s, val := range map[string]uint64{"000FS8SDRNE73": 555555555555555, "0C1SR": 12345, "0C1S": 12345} {
+                res, err := kee.FPIID.DecodeAs("crockford", s)
+                So(err, ShouldBeNil)
+                So(res.Int(), ShouldEqual, val)
+            }
+        })
+
+        Convey("Standard base 32 should still decode as before", func() {
+            res, err := kee.FPIID.Decode("4O4I-UW2G-7EAQ-A")
+            So(err, ShouldBeNil)
+            So(res.Int(), ShouldEqual, uint64(555555555555555))
+        })
+
+    })
+
+    Convey("When random IDs are encoded in Crockford base 32", t, func() {
+        r := rand.New(rand.NewSource(1))
+
+        Convey("Decode should read every 32-bit FPIID back", func() {
+            failed := 0
+            for i := 0; i < 20000; i++ {
+                val := uint64(r.Uint32())
+                res, err := kee.FPIID.Decode(kee.FPIID.FromInt(val).Crockford())
+                if err != nil || res.Int() != val { failed++ }
+            }
+            So(failed, ShouldEqual, 0)
+        })
+
+        Convey("Decode should read every V8 UUID back", func() {
+            failed := 0
+            for i := 0; i < 20000; i++ {
+                var custom [16]byte
+                r.Read(custom[:])
+                id, _ := kee.UUID.NewV8(custom)
+                res, err := kee.UUID.Decode(id.Crockford())
+                if err != nil || res.Arr() != id.Arr() { failed++ }
+            }
+            So(failed, ShouldEqual, 0)
+        })
+    })
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+    "encoding/hex"
+    "strings"
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+// upperHex is a minimal custom encoding for the registry tests
+type upperHex struct{}
+
+func (upperHex) EncodeToString(src []byte) string {
+    return strings.ToUpper(hex.EncodeToString(src))
+}
+
+func (upperHex) DecodeString(s string, size int) ([]byte, error) {
+    dst, err := hex.DecodeString(s)
+    if err == nil && size != 0 && len(dst) != size {
+        return []byte{}, hex.ErrLength
+    }
+    return dst, err
+}
+
+func TestEncodingRegistry(t *testing.T) {
+    kee.UUID.Options.Cache = false
+    kee.FPIID.Options.Cache = false
+
+    Convey("When a custom encoding is registered", t, func() {
+        kee.RegisterEncoding("upperhex", upperHex{})
+
+        Convey("Its name should not be taken twice", func() {
+            So(kee.RegisterEncoding("upperhex", upperHex{}), ShouldNotBeNil)
+            So(kee.RegisterEncoding("b64", upperHex{}), ShouldNotBeNil)
+        })
+
+        Convey("UUIDs should encode and decode with it", func() {
+            testVal := [16]byte{23, 22, 217, 229, 211, 95, 75, 134, 139, 156, 156, 34, 97, 225, 43, 143}
+            id := kee.UUID.Set(testVal)
+            s, err := id.Encode("upperhex")
+            So(err, ShouldBeNil)
+            So(s, ShouldEqual, "1716D9E5D35F4B868B9C9C2261E12B8F")
+            res, err := kee.UUID.DecodeAs("upperhex", s)
+            So(err, ShouldBeNil)
+            So(res.Arr(), ShouldEqual, testVal)
+        })
+
+        Convey("FPIIDs should pass it their big-endian value", func() {
+            id := kee.FPIID.FromInt(555555555555555)
+            s, err := id.Encode("upperhex")
+            So(err, ShouldBeNil)
+            So(s, ShouldEqual, "0001F9465B8AB8E3")
+            res, err := kee.FPIID.DecodeAs("upperhex", s)
+            So(err, ShouldBeNil)
+            So(res.Int(), ShouldEqual, uint64(555555555555555))
+            res, err = kee.FPIID.DecodeAs("upperhex", "FFFF")
+            So(err, ShouldBeNil)
+            So(res.Int(), ShouldEqual, uint64(65535))
+        })
+
+        Convey("APIIDs should encode and decode with it", func() {
+            id := kee.APIID.FromString("654654654654654654654654")
+            s, err := id.Encode("upperhex")
+            So(err, ShouldBeNil)
+            res, err := kee.APIID.DecodeAs("upperhex", s)
+            So(err, ShouldBeNil)
+            So(res.BigInt().String(), ShouldEqual, "654654654654654654654654")
+        })
+    })
+
+    Convey("When an encoding is looked up by name", t, func() {
+        testVal := [16]byte{23, 22, 217, 229, 211, 95, 75, 134, 139, 156, 156, 34, 97, 225, 43, 143}
+        id := kee.UUID.Set(testVal)
+
+        Convey("Built-in formats should match their methods", func() {
+            for name, want := range map[string]string{
+                "hex": id.Hex(), "urn": id.URN(), "a85": id.A85(),
+                "b64": id.B64(), "url64": id.URL64(), "b32": id.B32(),
+                "url32": id.URL32(), "crockford": id.Crockford(),
+                "b62": id.B62(), "b36": id.B36(),
+            } {
+                s, err := id.Encode(name)
+                So(err, ShouldBeNil)
+                So(s, ShouldEqual, want)
+                res, err := kee.UUID.DecodeAs(name, s)
+                So(err, ShouldBeNil)
+                So(res.Arr(), ShouldEqual, testVal)
+            }
+        })
+
+        Convey("Formats of the same length should be told apart", func() {
+            So(len(id.B62()), ShouldEqual, len(id.URL64()))
+            res, err := kee.UUID.DecodeAs("b62", id.B62())
+            So(err, ShouldBeNil)
+            So(res.Arr(), ShouldEqual, testVal)
+        })
+
+        Convey("Unknown names should return an error", func() {
+            _, err := id.Encode("nope")
+            So(err, ShouldNotBeNil)
+            _, err = kee.UUID.DecodeAs("nope", id.Hex())
+            So(err, ShouldNotBeNil)
+            _, err = kee.FPIID.DecodeAs("nope", "AAAA")
+            So(err, ShouldNotBeNil)
+            _, err = kee.APIID.DecodeAs("nope", "AAAA")
+            So(err, ShouldNotBeNil)
+        })
+    })
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+    "math/rand"
+    "sort"
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestFPIIDByteOrder(t *testing.T) {
+
+    Convey("When FPIIDs are encoded big-endian", t, func() {
+        opts := kee.FPIIDOptions
+        opts.Cache, opts.ByteOrder = false, kee.FPIIDBigEndian
+        ctrl := kee.FPIIDCtrl{Options: &opts}
+
+        Convey("Base 64 should match big-endian integer bytes", func() {
+            So(ctrl.FromInt(555555555555555).B64(), ShouldEqual, "AAH5RluKuOM=")
+            So(ctrl.FromInt(12345).B64(), ShouldEqual, "MDk=")
+            So(kee.FPIID.FromInt(12345).B64(), ShouldEqual, "OTA=")
+        })
+
+        Convey("Decode should read them back", func() {
+            res, err := ctrl.Decode("AAH5RluKuOM=")
+            So(err, ShouldBeNil)
+            So(res.Int(), ShouldEqual, uint64(555555555555555))
+            res, err = ctrl.Decode("MDk")
+            So(err, ShouldBeNil)
+            So(res.Int(), ShouldEqual, uint64(12345))
+        })
+    })
+
+    Convey("When FPIIDs are encoded sortably", t, func() {
+        opts := kee.FPIIDOptions
+        opts.Cache, opts.ByteOrder = false, kee.FPIIDSortable
+        ctrl := kee.FPIIDCtrl{Options: &opts}
+
+        vals := []uint64{0, 1, 63, 64, 65535, 65536, 1<<32, 1<<63, 1<<64 - 1}
+        r := rand.New(rand.NewSource(1))
+        for i := 0; i < 200; i++ {
+            vals = append(vals, r.Uint64() >> uint(r.Intn(64)))
+        }
+        sort.Slice(vals, func(i, j int) bool { return vals[i] < vals[j] })
+
+        Convey("Strings should sort in numeric order and round-trip", func() {
+            for _, name := range []string{"b64", "url64", "b32", "url32"} {
+                var strs []string
+                for _, val := range vals {
+                    s, err := ctrl.FromInt(val).Encode(name)
+                    So(err, ShouldBeNil)
+                    strs = append(strs, s)
+                    res, err := ctrl.Decode(s)
+                    So(err, ShouldBeNil)
+                    So(res.Int(), ShouldEqual, val)
+                }
+                So(sort.StringsAreSorted(strs), ShouldBeTrue)
+            }
+        })
+
+        Convey("Signed values should sort too", func() {
+            opts.Signed = true
+            var strs []string
+            for _, val := range []int64{-1 << 63, -70000, -5, -1, 0, 3, 70000, 1<<63 - 1} {
+                s := ctrl.FromInt64(val).URL64()
+                strs = append(strs, s)
+                res, err := ctrl.Decode(s)
+                So(err, ShouldBeNil)
+                So(res.Int64(), ShouldEqual, val)
+            }
+            So(sort.StringsAreSorted(strs), ShouldBeTrue)
+        })
+    })
+}
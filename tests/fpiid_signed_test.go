@@ -0,0 +1,69 @@
+package main
+
+import (
+    "math"
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestFPIIDSigned(t *testing.T) {
+
+    Convey("When a signed integer is set", t, func() {
+        opts := kee.FPIIDOptions
+        opts.Cache, opts.Signed = false, true
+        ctrl := kee.FPIIDCtrl{Options: &opts}
+
+        Convey("It should come back unchanged", func() {
+            So(kee.FPIID.FromInt64(-1).Int64(), ShouldEqual, int64(-1))
+            So(kee.FPIID.FromInt64(-1).Int(), ShouldEqual, uint64(math.MaxUint64))
+        })
+
+        Convey("Small negative values should get short strings", func() {
+            So(kee.FPIID.FromInt64(-1).URL64(), ShouldEqual, "__________8")
+            So(ctrl.FromInt64(-1).URL64(), ShouldEqual, "~AQA")
+            So(ctrl.FromInt64(1).URL64(), ShouldEqual, "~AgA")
+            So(len(ctrl.FromInt64(-40000).URL64()), ShouldEqual, 7)
+        })
+
+        Convey("Any handler should tell them apart from unsigned ones", func() {
+            for _, c := range []kee.FPIIDCtrl{ctrl, kee.FPIID} {
+                res, err := c.Decode("~AQA")
+                So(err, ShouldBeNil)
+                So(res.Int64(), ShouldEqual, int64(-1))
+                res, err = c.Decode("AQA")
+                So(err, ShouldBeNil)
+                So(res.Int64(), ShouldEqual, int64(1))
+                res, err = c.Decode(ctrl.FromInt64(-40000).B32())
+                So(err, ShouldBeNil)
+                So(res.Int64(), ShouldEqual, int64(-40000))
+            }
+        })
+
+        Convey("Errors should point into the string as given, mark included", func() {
+            _, err := ctrl.DecodeAs("url64", "~AB!")
+            e := asParseError(err)
+            So(e.Reason, ShouldEqual, kee.ParseIllegalChar)
+            So(e.Offset, ShouldEqual, 3)
+        })
+
+        Convey("Every encoding should round-trip", func() {
+            for _, val := range []int64{0, -1, 1, -32768, 32767, -40000, math.MinInt64, math.MaxInt64} {
+                id := ctrl.FromInt64(val)
+                for _, name := range []string{"b64", "url64", "b32", "url32", "crockford", "b62", "b36", "b58"} {
+                    s, err := id.Encode(name)
+                    So(err, ShouldBeNil)
+                    res, err := ctrl.DecodeAs(name, s)
+                    So(err, ShouldBeNil)
+                    So(res.Int64(), ShouldEqual, val)
+                    res, err = kee.FPIID.DecodeAs(name, s)
+                    So(err, ShouldBeNil)
+                    So(res.Int64(), ShouldEqual, val)
+                }
+                res, err := kee.FPIID.Decode(id.Crockford())
+                So(err, ShouldBeNil)
+                So(res.Int64(), ShouldEqual, val)
+            }
+        })
+    })
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestHOTP(t *testing.T) {
+
+    Convey("When the RFC 4226 test secret is set", t, func() {
+        secret := kee.HOTP.Set([]byte("12345678901234567890"))
+
+        Convey("Passwords should match Appendix D", func() {
+            want := []uint32{755224, 287082, 359152, 969429, 338314, 254676, 287922, 162583, 399871, 520489}
+            for counter, pwd := range want {
+                res, err := secret.Generate(uint64(counter))
+                So(err, ShouldBeNil)
+                So(res, ShouldEqual, pwd)
+            }
+        })
+
+        Convey("Verify should resynchronize within the window", func() {
+            next, err := secret.Verify(755224, 0, 3)
+            So(err, ShouldBeNil)
+            So(next, ShouldEqual, uint64(1))
+            next, err = secret.Verify(338314, 1, 3)
+            So(err, ShouldBeNil)
+            So(next, ShouldEqual, uint64(5))
+        })
+
+        Convey("Verify should reject passwords outside the window", func() {
+            next, err := secret.Verify(254676, 1, 3)
+            So(err, ShouldEqual, kee.ErrPasswordMismatch)
+            So(next, ShouldEqual, uint64(1))
+            _, err = secret.Verify(755224, 1, 3)
+            So(err, ShouldEqual, kee.ErrPasswordMismatch)
+        })
+
+        Convey("The URI should carry the counter", func() {
+            So(secret.URI("alice", "Example", 42), ShouldEqual,
+                "otpauth://hotp/alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" +
+                "&issuer=Example&algorithm=SHA1&digits=6&counter=42")
+        })
+    })
+
+    Convey("When a secret is decoded", t, func() {
+        secret, err := kee.HOTP.Decode("gezd-gnbv-gy3t-qojq-gezd-gnbv-gy3t-qojq")
+
+        Convey("It should make the same passwords", func() {
+            So(err, ShouldBeNil)
+            res, err := secret.Generate(9)
+            So(err, ShouldBeNil)
+            So(res, ShouldEqual, uint32(520489))
+        })
+    })
+}
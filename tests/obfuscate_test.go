@@ -0,0 +1,71 @@
+package main
+
+import (
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestFPIIDObfuscate(t *testing.T) {
+
+    Convey("When FPIIDs are obfuscated", t, func() {
+        opts := kee.FPIIDOptions
+        opts.Cache = false
+        opts.Obfuscate, opts.Secret = true, []byte("correct horse battery staple")
+        ctrl := kee.FPIIDCtrl{Options: &opts}
+        plain := kee.FPIID.FromInt(42)
+
+        Convey("Strings should differ but the value should not", func() {
+            id := ctrl.FromInt(42)
+            So(id.URL64(), ShouldNotEqual, plain.URL64())
+            So(len(id.URL64()), ShouldEqual, len(plain.URL64()))
+            So(id.Int(), ShouldEqual, uint64(42))
+        })
+
+        Convey("Only the same secret should decode them", func() {
+            s := ctrl.FromInt(42).URL64()
+            res, err := ctrl.Decode(s)
+            So(err, ShouldBeNil)
+            So(res.Int(), ShouldEqual, uint64(42))
+
+            other := opts
+            other.Secret = []byte("Tr0ub4dor&3")
+            res, err = kee.FPIIDCtrl{Options: &other}.Decode(s)
+            So(err, ShouldBeNil)
+            So(res.Int(), ShouldNotEqual, uint64(42))
+        })
+
+        Convey("Every encoding and width should round-trip", func() {
+            for _, val := range []uint64{0, 1, 42, 65535, 65536, 1 << 32, 1<<64 - 1} {
+                id := ctrl.FromInt(val)
+                for _, name := range []string{"b64", "url64", "b32", "url32", "crockford", "b62", "b36",
+                    "b58", "hex", "a85"} {
+                    s, err := id.Encode(name)
+                    So(err, ShouldBeNil)
+                    res, err := ctrl.DecodeAs(name, s)
+                    So(err, ShouldBeNil)
+                    So(res.Int(), ShouldEqual, val)
+                }
+            }
+        })
+
+        Convey("Sequential values should not give sequential strings", func() {
+            seen := make(map[string]bool)
+            for i := uint64(1); i <= 1000; i++ {
+                seen[ctrl.FromInt(i).B62()] = true
+            }
+            So(len(seen), ShouldEqual, 1000)
+            So(ctrl.FromInt(1).B62() < ctrl.FromInt(2).B62() &&
+                ctrl.FromInt(2).B62() < ctrl.FromInt(3).B62() &&
+                ctrl.FromInt(3).B62() < ctrl.FromInt(4).B62(), ShouldBeFalse)
+        })
+
+        Convey("A missing secret should return an error", func() {
+            opts.Secret = nil
+            _, err := ctrl.FromInt(42).Encode("url64")
+            So(err, ShouldNotBeNil)
+            _, err = ctrl.Decode("KgA")
+            So(err, ShouldNotBeNil)
+        })
+    })
+}
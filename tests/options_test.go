@@ -0,0 +1,45 @@
+package main
+
+import (
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestHandlerOptions(t *testing.T) {
+    Convey("When a second UUID handler is configured differently", t, func() {
+        opts := kee.UUIDOptions
+        opts.PadB64 = false
+        opts.MaxVer = 4
+        custom := kee.UUIDCtrl{Options: &opts, NS: kee.UUID.NS}
+        testVal := [16]byte{23, 22, 217, 229, 211, 95, 75, 134, 139, 156, 156, 34, 97, 225, 43, 143}
+
+        Convey("Each handler's UUIDs should use its own options", func() {
+            ida, idb := kee.UUID.Set(testVal), custom.Set(testVal)
+            So(ida.B64(), ShouldEqual, "FxbZ5dNfS4aLnJwiYeErjw==")
+            So(idb.B64(), ShouldEqual, "FxbZ5dNfS4aLnJwiYeErjw")
+        })
+
+        Convey("Each handler should validate with its own options", func() {
+            id, err := custom.NewV7()
+            So(err, ShouldNotBeNil)
+            So(id.IsValid(), ShouldEqual, false)
+            id, err = kee.UUID.NewV7()
+            So(err, ShouldBeNil)
+        })
+
+    })
+
+    Convey("When a second FPIID handler is configured differently", t, func() {
+        opts := kee.FPIIDOptions
+        opts.ShortStr = false
+        custom := kee.FPIIDCtrl{Options: &opts}
+
+        Convey("Each handler's FPIIDs should use its own options", func() {
+            ida, idb := kee.FPIID.FromInt(12345), custom.FromInt(12345)
+            So(ida.URL64(), ShouldEqual, "OTA")
+            So(idb.URL64(), ShouldEqual, "OTAAAAAAAAA")
+        })
+
+    })
+}
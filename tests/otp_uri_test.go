@@ -0,0 +1,132 @@
+package main
+
+import (
+    "testing"
+    "time"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestOTPParseURI(t *testing.T) {
+
+    Convey("When a URI made by URI is parsed", t, func() {
+        opts := kee.TOTPOptions
+        opts.Algorithm, opts.Digits, opts.Period = kee.OTPSHA512, 8, 60
+        secret := kee.TOTPCtrl{Options: &opts}.New()
+        uri := secret.URI("alice@example.com", "Example Co")
+        res, acct, err := kee.TOTP.ParseURI(uri)
+
+        Convey("It should round-trip with the secret's settings", func() {
+            So(err, ShouldBeNil)
+            So(acct, ShouldResemble, kee.OTPAccount{Type: "totp", Account: "alice@example.com", Issuer: "Example Co"})
+            So(res.Algorithm(), ShouldEqual, kee.OTPSHA512)
+            So(res.Digits(), ShouldEqual, 8)
+            So(res.Period(), ShouldEqual, 60)
+            So(res.URI(acct.Account, acct.Issuer), ShouldEqual, uri)
+            at := time.Unix(1234567890, 0)
+            a, _ := res.PasswordAt(at)
+            b, _ := secret.PasswordAt(at)
+            So(a, ShouldEqual, b)
+        })
+    })
+
+    Convey("When a URI from another app is parsed", t, func() {
+        res, acct, err := kee.TOTP.ParseURI("otpauth://totp/ACME%20Co:john.doe@email.com" +
+            "?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&issuer=ACME%20Co&algorithm=SHA1&digits=6&period=30")
+
+        Convey("The label should give the issuer and account", func() {
+            So(err, ShouldBeNil)
+            So(acct.Account, ShouldEqual, "john.doe@email.com")
+            So(acct.Issuer, ShouldEqual, "ACME Co")
+            So(res.B32(), ShouldEqual, "HXDM-VJEC-JJWS-RB3H-WIZR-4IFU-GFTM-XBOZ")
+        })
+
+        Convey("Missing parameters should take the standard defaults", func() {
+            opts := kee.TOTPOptions
+            opts.Algorithm, opts.Digits = kee.OTPSHA256, 8
+            res, acct, err := kee.TOTPCtrl{Options: &opts}.ParseURI("otpauth://totp/Example:bob?secret=gezdgnbvgy3tqojq")
+            So(err, ShouldBeNil)
+            So(acct.Issuer, ShouldEqual, "Example")
+            So(res.Algorithm(), ShouldEqual, kee.OTPSHA1)
+            So(res.Digits(), ShouldEqual, 6)
+            So(res.Period(), ShouldEqual, 30)
+        })
+
+        Convey("A plus sign in the label should stay a plus sign", func() {
+            _, acct, err := kee.TOTP.ParseURI("otpauth://totp/Example:alice+tag@example.com?secret=GEZDGNBVGY3TQOJQ")
+            So(err, ShouldBeNil)
+            So(acct.Account, ShouldEqual, "alice+tag@example.com")
+        })
+    })
+
+    Convey("When a label has a plus sign or spaces", t, func() {
+        secret := kee.TOTP.Set([]byte("12345678901234567890"))
+        uri := secret.URI("alice+tag @example.com", "ACME Co")
+
+        Convey("It should be path-escaped and come back unchanged", func() {
+            So(uri, ShouldStartWith, "otpauth://totp/alice+tag%20@example.com?")
+            _, acct, err := kee.TOTP.ParseURI(uri)
+            So(err, ShouldBeNil)
+            So(acct.Account, ShouldEqual, "alice+tag @example.com")
+            So(acct.Issuer, ShouldEqual, "ACME Co")
+        })
+    })
+
+    Convey("When an account name has a colon", t, func() {
+        totp := kee.TOTP.Set([]byte("12345678901234567890"))
+        hotp := kee.HOTP.Set([]byte("12345678901234567890"))
+
+        Convey("It should be escaped and come back whole", func() {
+            uri := totp.URI("team:alice", "ACME")
+            So(uri, ShouldStartWith, "otpauth://totp/team%3Aalice?")
+            _, acct, err := kee.TOTP.ParseURI(uri)
+            So(err, ShouldBeNil)
+            So(acct.Account, ShouldEqual, "team:alice")
+            So(acct.Issuer, ShouldEqual, "ACME")
+            _, acct, err = kee.HOTP.ParseURI(hotp.URI("team:alice", "ACME", 3))
+            So(err, ShouldBeNil)
+            So(acct.Account, ShouldEqual, "team:alice")
+        })
+
+        Convey("An issuer prefix should still be split off at the first plain colon", func() {
+            _, acct, err := kee.TOTP.ParseURI("otpauth://totp/ACME:team%3Aalice?secret=GEZDGNBVGY3TQOJQ")
+            So(err, ShouldBeNil)
+            So(acct.Issuer, ShouldEqual, "ACME")
+            So(acct.Account, ShouldEqual, "team:alice")
+        })
+    })
+
+    Convey("When an HOTP URI is parsed", t, func() {
+        secret := kee.HOTP.Set([]byte("12345678901234567890"))
+        uri := secret.URI("alice", "Example", 7)
+        res, acct, err := kee.HOTP.ParseURI(uri)
+
+        Convey("The counter should come back and passwords should match", func() {
+            So(err, ShouldBeNil)
+            So(acct.Type, ShouldEqual, "hotp")
+            So(acct.Counter, ShouldEqual, uint64(7))
+            So(res.URI(acct.Account, acct.Issuer, acct.Counter), ShouldEqual, uri)
+            pwd, _ := res.Generate(7)
+            So(pwd, ShouldEqual, uint32(162583))
+        })
+    })
+
+    Convey("When a URI is malformed", t, func() {
+        for _, uri := range []string{
+            "https://totp/alice?secret=GEZDGNBVGY3TQOJQ",
+            "otpauth://motp/alice?secret=GEZDGNBVGY3TQOJQ",
+            "otpauth://totp/alice",
+            "otpauth://totp/alice?secret=G",
+            "otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ&algorithm=MD5",
+            "otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ&digits=12",
+            "otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ&period=0",
+            "otpauth://hotp/alice?secret=GEZDGNBVGY3TQOJQ",
+        } {
+            _, _, err := kee.TOTP.ParseURI(uri)
+
+            Convey("It should be rejected: " + uri, func() {
+                So(err, ShouldNotBeNil)
+            })
+        }
+    })
+}
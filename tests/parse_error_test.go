@@ -0,0 +1,92 @@
+package main
+
+import (
+    "errors"
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+// asParseError returns err as a *kee.ParseError, or an empty one if it isn't
+func asParseError(err error) *kee.ParseError {
+    var e *kee.ParseError
+    if errors.As(err, &e) { return e }
+    return &kee.ParseError{}
+}
+
+func TestParseError(t *testing.T) {
+    kee.UUID.Options.Cache = false
+
+    Convey("When a UUID string fails to decode", t, func() {
+
+        Convey("An illegal character should be located", func() {
+            _, err := kee.UUID.DecodeAs("b64", "FxbZ5dNfS4aLnJwi*eErjw==")
+            e := asParseError(err)
+            So(e.Format, ShouldEqual, "b64")
+            So(e.Reason, ShouldEqual, kee.ParseIllegalChar)
+            So(e.Offset, ShouldEqual, 16)
+            So(err.Error(), ShouldEqual, "illegal character in b64 string at input byte 16")
+
+            _, err = kee.UUID.Decode("urn:uuid:1716d9e5-d35f-4b86-8b9c-9c2261e12bXf")
+            e = asParseError(err)
+            So(e.Format, ShouldEqual, "hex")
+            So(e.Reason, ShouldEqual, kee.ParseIllegalChar)
+            So(e.Offset, ShouldEqual, 43)
+        })
+
+        Convey("Misplaced padding should be reported", func() {
+            _, err := kee.UUID.Decode("FxbZ5dNfS4aLnJwiYeEr=jw=")
+            e := asParseError(err)
+            So(e.Reason, ShouldEqual, kee.ParseBadPadding)
+            So(e.Offset, ShouldEqual, 21)
+        })
+
+        Convey("A length matching no encoding should be reported", func() {
+            _, err := kee.UUID.Decode("abc")
+            e := asParseError(err)
+            So(e.Format, ShouldEqual, "")
+            So(e.Reason, ShouldEqual, kee.ParseBadLength)
+            So(e.Offset, ShouldEqual, -1)
+        })
+
+        Convey("A bad check symbol should be reported", func() {
+            kee.UUID.Options.CrockfordCheck = true
+            _, err := kee.UUID.DecodeAs("crockford", "0Q2V-CYBM-TZ9E-38Q7-4W49-GY2A-WFN")
+            kee.UUID.Options.CrockfordCheck = false
+            e := asParseError(err)
+            So(e.Reason, ShouldEqual, kee.ParseBadCheck)
+            So(e.Offset, ShouldEqual, 32)
+        })
+
+        Convey("A UUID of a disallowed version should be reported", func() {
+            _, err := kee.UUID.Decode("1716d9e5-d35f-0b86-8b9c-9c2261e12b8f")
+            e := asParseError(err)
+            So(e.Format, ShouldEqual, "hex")
+            So(e.Reason, ShouldEqual, kee.ParseInvalidVersion)
+        })
+
+        Convey("An unknown format should be reported", func() {
+            _, err := kee.UUID.DecodeAs("nope", "1716d9e5-d35f-4b86-8b9c-9c2261e12b8f")
+            So(asParseError(err).Reason, ShouldEqual, kee.ParseUnknownFormat)
+        })
+    })
+
+    Convey("When other IDs fail to decode", t, func() {
+
+        Convey("FPIIDs should report the character, not the size", func() {
+            _, err := kee.FPIID.DecodeAs("b62", "002Xkrl8SU-")
+            e := asParseError(err)
+            So(e.Format, ShouldEqual, "b62")
+            So(e.Reason, ShouldEqual, kee.ParseIllegalChar)
+            So(e.Offset, ShouldEqual, 10)
+        })
+
+        Convey("APIIDs should report illegal base 58", func() {
+            _, err := kee.APIID.Decode("hr0dG")
+            e := asParseError(err)
+            So(e.Format, ShouldEqual, "b58")
+            So(e.Reason, ShouldEqual, kee.ParseIllegalChar)
+            So(e.Offset, ShouldEqual, 2)
+        })
+    })
+}
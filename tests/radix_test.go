@@ -0,0 +1,87 @@
+package main
+
+import (
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestRadix(t *testing.T) {
+    kee.UUID.Options.Cache = false
+    kee.FPIID.Options.Cache = false
+
+    Convey("When a UUID is encoded in base 62 and base 36", t, func() {
+        testVal := [16]byte{23, 22, 217, 229, 211, 95, 75, 134, 139, 156, 156, 34, 97, 225, 43, 143}
+        id := kee.UUID.Set(testVal)
+
+        Convey("It should match the expected values", func() {
+            So(id.B62(), ShouldEqual, "0hZGWXtrKQCTRuuQmgepep")
+            So(id.B36(), ShouldEqual, "1d7jynhbd40005oscsgje5w27")
+        })
+
+        Convey("Decoding with an explicit format should restore it", func() {
+            res, err := kee.UUID.DecodeAs("b62", "0hZGWXtrKQCTRuuQmgepep")
+            So(err, ShouldBeNil)
+            So(res.Arr(), ShouldEqual, testVal)
+            res, err = kee.UUID.DecodeAs("b36", "1D7JYNHBD40005OSCSGJE5W27")
+            So(err, ShouldBeNil)
+            So(res.Arr(), ShouldEqual, testVal)
+        })
+
+        Convey("Decode should not restore it, so DecodeAs is needed", func() {
+            for _, s := range []string{id.B62(), id.B36()} {
+                res, err := kee.UUID.Decode(s)
+                So(err != nil || res.Arr() != testVal, ShouldBeTrue)
+            }
+            opts := kee.UUIDOptions
+            opts.AllowInvalid = true
+            res, err := kee.UUIDCtrl{Options: &opts}.Decode(id.B62())
+            So(err, ShouldBeNil) // Read as base 64, silently
+            So(res.Arr(), ShouldNotEqual, testVal)
+        })
+
+    })
+
+    Convey("When an FPIID is encoded in base 62 and base 36", t, func() {
+        id := kee.FPIID.FromInt(555555555555555)
+
+        Convey("It should be zero-padded to a fixed width", func() {
+            So(id.B62(), ShouldEqual, "002Xkrl8SUV")
+            So(id.B36(), ShouldEqual, "0005gxep5raw3")
+            So(len(kee.FPIID.FromInt(1).B62()), ShouldEqual, 11)
+        })
+
+        Convey("Decoding with an explicit format should restore it", func() {
+            res, err := kee.FPIID.DecodeAs("b62", "002Xkrl8SUV")
+            So(err, ShouldBeNil)
+            So(res.Int(), ShouldEqual, uint64(555555555555555))
+            res, err = kee.FPIID.DecodeAs("b36", "0005gxep5raw3")
+            So(err, ShouldBeNil)
+            So(res.Int(), ShouldEqual, uint64(555555555555555))
+        })
+
+        Convey("Smaller values should sort first", func() {
+            a, b := kee.FPIID.FromInt(61), kee.FPIID.FromInt(62)
+            So(a.B62() < b.B62(), ShouldEqual, true)
+        })
+
+    })
+
+    Convey("When an APIID is encoded in base 62 and base 36", t, func() {
+        id := kee.APIID.FromString("654654654654654654654654")
+
+        Convey("It should match the expected values", func() {
+            So(id.B62(), ShouldEqual, "3GufMthYE8RBi2")
+            So(id.B36(), ShouldEqual, "2ylrz5mkthet2awu")
+        })
+
+        Convey("Decoding with an explicit format should restore it", func() {
+            res, err := kee.APIID.DecodeAs("b62", "3GufMthYE8RBi2")
+            So(err, ShouldBeNil)
+            So(res.BigInt().String(), ShouldEqual, "654654654654654654654654")
+            _, err = kee.APIID.DecodeAs("b62", "3Guf-MthYE8RBi2")
+            So(err, ShouldNotBeNil)
+        })
+
+    })
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+    "sync"
+    "testing"
+    "time"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestFPIIDNew(t *testing.T) {
+
+    Convey("When FPIIDs are generated", t, func() {
+        opts := kee.FPIIDOptions
+        opts.WorkerID = 7
+        ctrl := kee.FPIIDCtrl{Options: &opts}
+
+        Convey("They should decompose into time, worker and sequence", func() {
+            before := time.Now().Add(-time.Millisecond)
+            id, err := ctrl.New()
+            So(err, ShouldBeNil)
+            So(id.WorkerID(), ShouldEqual, uint64(7))
+            So(id.Timestamp().Before(before), ShouldBeFalse)
+            So(id.Timestamp().After(time.Now()), ShouldBeFalse)
+            So(id.Int() >> 63, ShouldEqual, uint64(0))
+        })
+
+        Convey("They should be unique and ordered across goroutines", func() {
+            var mu sync.Mutex
+            var wg sync.WaitGroup
+            seen := make(map[uint64]bool)
+            dupes, unordered := 0, 0
+            for g := 0; g < 8; g++ {
+                wg.Add(1)
+                go func() {
+                    defer wg.Done()
+                    var last uint64
+                    for i := 0; i < 2000; i++ {
+                        id, _ := ctrl.New()
+                        mu.Lock()
+                        if id.Int() <= last { unordered++ }
+                        last = id.Int()
+                        if seen[last] { dupes++ }
+                        seen[last] = true
+                        mu.Unlock()
+                    }
+                }()
+            }
+            wg.Wait()
+            So(dupes, ShouldEqual, 0)
+            So(unordered, ShouldEqual, 0)
+        })
+
+        Convey("A full sequence should move on to the next millisecond", func() {
+            opts.SeqBits = 1
+            a, _ := ctrl.New()
+            b, _ := ctrl.New()
+            c, _ := ctrl.New()
+            So(a.Int() < b.Int() && b.Int() < c.Int(), ShouldBeTrue)
+            So(c.Timestamp().After(a.Timestamp()), ShouldBeTrue)
+        })
+
+        Convey("Layouts that don't fit should return an error", func() {
+            opts.WorkerBits = 30
+            _, err := ctrl.New()
+            So(err, ShouldNotBeNil)
+            opts.WorkerBits, opts.WorkerID = 2, 7
+            _, err = ctrl.New()
+            So(err, ShouldNotBeNil)
+        })
+    })
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestSQL(t *testing.T) {
+    kee.UUID.Options.Cache = false
+
+    Convey("When a UUID is stored in a database", t, func() {
+        testVal := [16]byte{23, 22, 217, 229, 211, 95, 75, 134, 139, 156, 156, 34, 97, 225, 43, 143}
+        id := kee.UUID.Set(testVal)
+
+        Convey("It should be stored as 16 bytes by default", func() {
+            v, err := id.Value()
+            So(err, ShouldBeNil)
+            So(len(v.([]byte)), ShouldEqual, 16)
+            var res kee.KUUID
+            So(res.Scan(v), ShouldBeNil)
+            So(res.Arr(), ShouldEqual, testVal)
+        })
+
+        Convey("It should be stored as hex if SQLText is set", func() {
+            kee.UUID.Options.SQLText = true
+            v, err := id.Value()
+            kee.UUID.Options.SQLText = false
+            So(err, ShouldBeNil)
+            So(v, ShouldEqual, "1716d9e5-d35f-4b86-8b9c-9c2261e12b8f")
+            var res kee.KUUID
+            So(res.Scan(v), ShouldBeNil)
+            So(res.Arr(), ShouldEqual, testVal)
+        })
+
+        Convey("NULL should only scan into NullUUID", func() {
+            var res kee.KUUID
+            So(res.Scan(nil), ShouldNotBeNil)
+            nu := kee.NullUUID{UUID: id, Valid: true}
+            So(nu.Scan(nil), ShouldBeNil)
+            So(nu.Valid, ShouldEqual, false)
+            v, _ := nu.Value()
+            So(v, ShouldBeNil)
+        })
+
+    })
+
+    Convey("When integer IDs are stored in a database", t, func() {
+
+        Convey("FPIIDs should round-trip through BIGINT", func() {
+            id := kee.FPIID.FromInt(18446744073709551615)
+            v, err := id.Value()
+            So(err, ShouldBeNil)
+            So(v, ShouldEqual, int64(-1))
+            var res kee.KFPIID
+            So(res.Scan(v), ShouldBeNil)
+            So(res.Int(), ShouldEqual, uint64(18446744073709551615))
+        })
+
+        Convey("APIIDs should round-trip through NUMERIC", func() {
+            id := kee.APIID.FromString("654654654654654654654654")
+            v, err := id.Value()
+            So(err, ShouldBeNil)
+            So(v, ShouldEqual, "654654654654654654654654")
+            var res kee.KAPIID
+            So(res.Scan([]byte("654654654654654654654654")), ShouldBeNil)
+            So(res.B58(), ShouldEqual, id.B58())
+        })
+
+        Convey("Negative values should not scan into APIIDs", func() {
+            var res kee.KAPIID
+            So(res.Scan(int64(-5)), ShouldNotBeNil)
+            So(res.Scan("-7"), ShouldNotBeNil)
+            So(res.Scan([]byte("-7")), ShouldNotBeNil)
+        })
+
+        Convey("An APIID of 0 should round-trip, not become NULL", func() {
+            v, err := kee.APIID.FromInt(0).Value()
+            So(err, ShouldBeNil)
+            So(v, ShouldEqual, "0")
+            var res kee.KAPIID
+            So(res.Scan(v), ShouldBeNil)
+            So(res.BigInt().Sign(), ShouldEqual, 0)
+            v, err = kee.KAPIID{}.Value()
+            So(err, ShouldBeNil)
+            So(v, ShouldBeNil)
+        })
+
+    })
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+    "testing"
+    "time"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestTOTPVectors(t *testing.T) {
+
+    Convey("When passwords are made at the times of RFC 6238 Appendix B", t, func() {
+        seeds := map[kee.OTPAlgorithm]string{
+            kee.OTPSHA1:   "12345678901234567890",
+            kee.OTPSHA256: "12345678901234567890123456789012",
+            kee.OTPSHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+        }
+        vectors := []struct {
+            at int64
+            want map[kee.OTPAlgorithm]uint32
+        }{
+            {59, map[kee.OTPAlgorithm]uint32{kee.OTPSHA1: 94287082, kee.OTPSHA256: 46119246, kee.OTPSHA512: 90693936}},
+            {1111111109, map[kee.OTPAlgorithm]uint32{kee.OTPSHA1: 7081804, kee.OTPSHA256: 68084774, kee.OTPSHA512: 25091201}},
+            {1111111111, map[kee.OTPAlgorithm]uint32{kee.OTPSHA1: 14050471, kee.OTPSHA256: 67062674, kee.OTPSHA512: 99943326}},
+            {1234567890, map[kee.OTPAlgorithm]uint32{kee.OTPSHA1: 89005924, kee.OTPSHA256: 91819424, kee.OTPSHA512: 93441116}},
+            {2000000000, map[kee.OTPAlgorithm]uint32{kee.OTPSHA1: 69279037, kee.OTPSHA256: 90698825, kee.OTPSHA512: 38618901}},
+            {20000000000, map[kee.OTPAlgorithm]uint32{kee.OTPSHA1: 65353130, kee.OTPSHA256: 77737706, kee.OTPSHA512: 47863826}},
+        }
+        opts := kee.TOTPOptions
+        opts.WholeSecret, opts.Digits = true, 8
+
+        Convey("PasswordAt should match every vector", func() {
+            for alg, seed := range seeds {
+                opts.Algorithm = alg
+                secret := kee.TOTPCtrl{Options: &opts}.Set([]byte(seed))
+                for _, v := range vectors {
+                    pwd, err := secret.PasswordAt(time.Unix(v.at, 0))
+                    So(err, ShouldBeNil)
+                    So(pwd, ShouldEqual, v.want[alg])
+                }
+            }
+        })
+
+        Convey("MakePassword should match them on an injected clock", func() {
+            opts.Algorithm = kee.OTPSHA256
+            for _, v := range vectors {
+                at := time.Unix(v.at, 0)
+                ctrl := kee.TOTPCtrl{Options: &opts, Clock: func() time.Time { return at }}
+                secret := ctrl.Set([]byte(seeds[kee.OTPSHA256]))
+                pwds, err := secret.MakePassword()
+                So(err, ShouldBeNil)
+                So(pwds[0], ShouldEqual, v.want[kee.OTPSHA256])
+            }
+        })
+    })
+
+    Convey("When a time is split into steps", t, func() {
+        secret := kee.TOTP.Set([]byte("12345678901234567890"))
+
+        Convey("StepAt and Remaining should agree with the period", func() {
+            So(secret.StepAt(time.Unix(59, 0)), ShouldEqual, int64(1))
+            So(secret.StepAt(time.Unix(60, 0)), ShouldEqual, int64(2))
+            So(secret.StepAt(time.Unix(1111111109, 0)), ShouldEqual, int64(0x23523EC))
+            So(secret.Remaining(time.Unix(59, 0)), ShouldEqual, time.Second)
+            So(secret.Remaining(time.Unix(60, 0)), ShouldEqual, 30 * time.Second)
+            So(secret.Remaining(time.Unix(61, 500000000)), ShouldEqual, 28500 * time.Millisecond)
+        })
+    })
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+    "strings"
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestTOTPOptions(t *testing.T) {
+
+    Convey("When a TOTP handler uses SHA-256, 8 digits and 60-second periods", t, func() {
+        opts := kee.TOTPOptions
+        opts.Algorithm, opts.Digits, opts.Period = kee.OTPSHA256, 8, 60
+        ctrl := kee.TOTPCtrl{Options: &opts}
+        secret := ctrl.Set([]byte("12345678901234567890"))
+
+        Convey("The secret should report them", func() {
+            So(secret.Algorithm(), ShouldEqual, kee.OTPSHA256)
+            So(secret.Digits(), ShouldEqual, 8)
+            So(secret.Period(), ShouldEqual, 60)
+            def := kee.TOTP.Set([]byte("12345678901234567890"))
+            So(def.Algorithm(), ShouldEqual, kee.OTPSHA1)
+            So(def.Digits(), ShouldEqual, 6)
+            So(def.Period(), ShouldEqual, 30)
+        })
+
+        Convey("Passwords should have up to 8 digits", func() {
+            pwds, err := secret.MakePassword()
+            So(err, ShouldBeNil)
+            So(len(pwds), ShouldEqual, 3)
+            for _, pwd := range pwds {
+                So(pwd, ShouldBeLessThan, 100000000)
+            }
+        })
+
+        Convey("The URI should carry them, with an unhyphenated secret", func() {
+            uri := secret.URI("alice@example.com", "Example Co")
+            So(uri, ShouldEqual, "otpauth://totp/alice@example.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" +
+                "&issuer=Example+Co&algorithm=SHA256&digits=8&period=60")
+        })
+
+        Convey("Bad settings should return errors", func() {
+            opts.Digits = 5
+            _, err := secret.MakePassword()
+            So(err, ShouldNotBeNil)
+            opts.Digits, opts.Algorithm = 8, kee.OTPAlgorithm("MD5")
+            _, err = secret.MakePassword()
+            So(err, ShouldNotBeNil)
+        })
+    })
+
+    Convey("When secrets are kept whole", t, func() {
+        opts := kee.TOTPOptions
+        opts.WholeSecret = true
+        ctrl := kee.TOTPCtrl{Options: &opts}
+        secret := ctrl.Set([]byte("1234567890123456789012345678901234567890123456789012345678901234"))
+
+        Convey("Every byte should be in the base 32 secret and decode back", func() {
+            s := strings.Replace(secret.B32(), "-", "", -1)
+            So(len(s), ShouldEqual, 103)
+            res, err := ctrl.Decode(secret.B32())
+            So(err, ShouldBeNil)
+            So(res.B32(), ShouldEqual, secret.B32())
+            a, _ := res.MakePassword()
+            b, _ := secret.MakePassword()
+            So(a, ShouldResemble, b)
+        })
+    })
+
+    Convey("When B32Blocks is 0 or below", t, func() {
+        opts := kee.TOTPOptions
+        ctrl := kee.TOTPCtrl{Options: &opts}
+
+        Convey("Secrets should be cut to 4 blocks, as they always were", func() {
+            for _, blocks := range []int{0, -1} {
+                opts.B32Blocks = blocks
+                secret := ctrl.Set([]byte("12345678901234567890"))
+                So(secret.B32(), ShouldEqual, "GEZD-GNBV-GY3T-QOJQ")
+                _, err := ctrl.Decode("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+                So(err, ShouldNotBeNil)
+            }
+        })
+    })
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+    "testing"
+    "time"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestTOTPVerify(t *testing.T) {
+
+    Convey("When TOTP passwords are verified", t, func() {
+        opts := kee.TOTPOptions
+        ctrl := kee.TOTPCtrl{Options: &opts}
+        secret := ctrl.Set([]byte("12345678901234567890"))
+        counter := kee.HOTP.Set([]byte("12345678901234567890")) // same passwords, by step
+        at := time.Unix(1111111109, 0)
+        now := at.Unix() / 30
+        code := func(step int64) uint32 {
+            pwd, _ := counter.Generate(uint64(step))
+            return pwd
+        }
+
+        Convey("The matching step and its drift should be returned", func() {
+            step, drift, err := secret.Verify(code(now), at)
+            So(err, ShouldBeNil)
+            So(step, ShouldEqual, now)
+            So(drift, ShouldEqual, 0)
+            step, drift, err = secret.Verify(code(now - 1), at)
+            So(err, ShouldBeNil)
+            So(step, ShouldEqual, now - 1)
+            So(drift, ShouldEqual, -1)
+            _, drift, err = secret.Verify(code(now + 1), at)
+            So(err, ShouldBeNil)
+            So(drift, ShouldEqual, 1)
+        })
+
+        Convey("Passwords outside the window should be rejected", func() {
+            _, _, err := secret.Verify(code(now - 2), at)
+            So(err, ShouldEqual, kee.ErrPasswordMismatch)
+            _, _, err = secret.Verify(code(now + 2), at)
+            So(err, ShouldEqual, kee.ErrPasswordMismatch)
+        })
+
+        Convey("With a store, a password should only be accepted once", func() {
+            opts.UsedCodes = kee.NewMemoryCodeStore()
+            _, _, err := secret.Verify(code(now), at)
+            So(err, ShouldBeNil)
+            _, _, err = secret.Verify(code(now), at)
+            So(err, ShouldEqual, kee.ErrPasswordReused)
+            _, _, err = secret.Verify(code(now - 1), at)
+            So(err, ShouldEqual, kee.ErrPasswordReused)
+            _, _, err = secret.Verify(code(now + 1), at)
+            So(err, ShouldBeNil)
+
+            other := ctrl.Set([]byte("09876543210987654321"))
+            pwds, _ := other.MakePassword()
+            _, _, err = other.Verify(pwds[0], time.Now())
+            So(err, ShouldBeNil)
+        })
+    })
+}
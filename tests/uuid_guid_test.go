@@ -0,0 +1,56 @@
+package main
+
+import (
+    "testing"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+func TestUUIDGUID(t *testing.T) {
+    kee.UUID.Options.Cache = false
+
+    testVal := [16]byte{23, 22, 217, 229, 211, 95, 75, 134, 139, 156, 156, 34, 97, 225, 43, 143}
+
+    Convey("When a UUID is decoded from other hex forms", t, func() {
+
+        Convey("Braces, missing hyphens and upper case should be accepted", func() {
+            for _, s := range []string{
+                "{1716d9e5-d35f-4b86-8b9c-9c2261e12b8f}",
+                "{1716D9E5-D35F-4B86-8B9C-9C2261E12B8F}",
+                "1716d9e5d35f4b868b9c9c2261e12b8f",
+                "{1716d9e5d35f4b868b9c9c2261e12b8f}",
+                "URN:UUID:1716D9E5-D35F-4B86-8B9C-9C2261E12B8F",
+                "urn:uuid:1716d9e5d35f4b868b9c9c2261e12b8f",
+            } {
+                id, err := kee.UUID.Decode(s)
+                So(err, ShouldBeNil)
+                So(id.Arr(), ShouldEqual, testVal)
+            }
+        })
+
+        Convey("Hyphenated base 32 of the same length should still decode", func() {
+            id, err := kee.UUID.Decode("C4LN-TZOT-L5FY-NC44-TQRG-DYJL-R4")
+            So(err, ShouldBeNil)
+            So(id.Arr(), ShouldEqual, testVal)
+        })
+    })
+
+    Convey("When a UUID is converted to Microsoft GUID byte order", t, func() {
+        id := kee.UUID.Set(testVal)
+        guid := id.GUIDBytes()
+
+        Convey("The first three fields should be little-endian", func() {
+            So(guid, ShouldResemble, []byte{
+                229, 217, 22, 23, 95, 211, 134, 75,
+                139, 156, 156, 34, 97, 225, 43, 143})
+        })
+
+        Convey("Setting the GUID bytes should restore it", func() {
+            res, err := kee.UUID.SetGUIDBytes(guid)
+            So(err, ShouldBeNil)
+            So(res.Arr(), ShouldEqual, testVal)
+            _, err = kee.UUID.SetGUIDBytes(guid[:8])
+            So(err, ShouldNotBeNil)
+        })
+    })
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+    "testing"
+    "encoding/json"
+    . "github.com/smartystreets/goconvey/convey"
+    "github.com/Sam-Izdat/kee"
+)
+
+type uuidHolder struct {
+    ID kee.KUUID `json:"id"`
+}
+
+func TestUUIDMarshal(t *testing.T) {
+    kee.UUID.Options.Cache = false
+
+    Convey("When a UUID is marshaled", t, func() {
+        testVal := [16]byte{23, 22, 217, 229, 211, 95, 75, 134, 139, 156, 156, 34, 97, 225, 43, 143}
+        id := kee.UUID.Set(testVal)
+
+        Convey("JSON should use the hex form by default", func() {
+            b, err := json.Marshal(uuidHolder{id})
+            So(err, ShouldBeNil)
+            So(string(b), ShouldEqual, `{"id":"1716d9e5-d35f-4b86-8b9c-9c2261e12b8f"}`)
+        })
+
+        Convey("JSON should use the TextFormat option", func() {
+            kee.UUID.Options.TextFormat = "url64"
+            b, err := json.Marshal(uuidHolder{id})
+            kee.UUID.Options.TextFormat = "hex"
+            So(err, ShouldBeNil)
+            So(string(b), ShouldEqual, `{"id":"FxbZ5dNfS4aLnJwiYeErjw"}`)
+        })
+
+        Convey("An empty UUID should marshal to null", func() {
+            b, err := json.Marshal(uuidHolder{})
+            So(err, ShouldBeNil)
+            So(string(b), ShouldEqual, `{"id":null}`)
+        })
+
+        Convey("Unmarshaling any decodable form should restore it", func() {
+            for _, s := range []string{
+                `"1716d9e5-d35f-4b86-8b9c-9c2261e12b8f"`,
+                `"FxbZ5dNfS4aLnJwiYeErjw"`,
+                `"C4LN-TZOT-L5FY-NC44-TQRG-DYJL-R4"`} {
+                var res uuidHolder
+                err := json.Unmarshal([]byte(`{"id":`+s+`}`), &res)
+                So(err, ShouldBeNil)
+                So(res.ID.Arr(), ShouldEqual, testVal)
+            }
+        })
+
+        Convey("Binary marshaling should round-trip", func() {
+            b, err := id.MarshalBinary()
+            So(err, ShouldBeNil)
+            var res kee.KUUID
+            So(res.UnmarshalBinary(b), ShouldBeNil)
+            So(kee.UUID.Match(res, id), ShouldEqual, true)
+        })
+
+        Convey("The nil UUID should round-trip", func() {
+            nilID := kee.UUID.Set([16]byte{})
+            b, err := json.Marshal(uuidHolder{nilID})
+            So(err, ShouldBeNil)
+            So(string(b), ShouldEqual, `{"id":"00000000-0000-0000-0000-000000000000"}`)
+            var res uuidHolder
+            So(json.Unmarshal(b, &res), ShouldBeNil)
+            So(res.ID.Arr(), ShouldEqual, [16]byte{})
+            So(len(res.ID.Slc()), ShouldEqual, 16)
+
+            bin, err := nilID.MarshalBinary()
+            So(err, ShouldBeNil)
+            var out kee.KUUID
+            So(out.UnmarshalBinary(bin), ShouldBeNil)
+            So(out.Arr(), ShouldEqual, [16]byte{})
+            So(len(out.Slc()), ShouldEqual, 16)
+        })
+
+        Convey("An empty UUID should round-trip as binary", func() {
+            b, err := kee.KUUID{}.MarshalBinary()
+            So(err, ShouldBeNil)
+            So(len(b), ShouldEqual, 0)
+            res := id
+            So(res.UnmarshalBinary(b), ShouldBeNil)
+            So(len(res.Slc()), ShouldEqual, 0)
+        })
+
+    })
+}
@@ -3,6 +3,7 @@ package main
 import (
     "fmt"
     "testing"
+    "time"
     . "github.com/smartystreets/goconvey/convey"
     "github.com/Sam-Izdat/kee"
 )
@@ -61,3 +62,167 @@ func TestUUIDNew(t *testing.T) {
 
     })
 }
+
+func TestUUIDNewV7(t *testing.T) {
+    Convey("When a V7 UUID is generated", t, func() {
+
+        before := time.Now().Unix()
+        id, err := kee.UUID.NewV7()
+
+        Convey("No error should be returned", func() {
+            So(err, ShouldBeNil)
+        })
+
+        Convey("It should be valid Version 7", func() {
+            So(id.IsValid(), ShouldEqual, true)
+            So(id.Version().String(), ShouldEqual, "VERSION_7")
+            So(id.Variant().String(), ShouldEqual, "RFC4122")
+        })
+
+        Convey("Its timestamp should be the time of creation", func() {
+            ts, ok := id.Time()
+            sec, _ := ts.UnixTime()
+            So(ok, ShouldEqual, true)
+            So(sec >= before && sec <= time.Now().Unix(), ShouldEqual, true)
+        })
+
+        Convey("Decoding its hex string should succeed", func() {
+            res, err := kee.UUID.Decode(id.Hex())
+            So(err, ShouldBeNil)
+            So(kee.UUID.Match(res, id), ShouldEqual, true)
+        })
+
+    })
+}
+
+func TestUUIDNewV6(t *testing.T) {
+    Convey("When a V6 UUID is generated", t, func() {
+
+        id, err := kee.UUID.NewV6()
+
+        Convey("No error should be returned", func() {
+            So(err, ShouldBeNil)
+        })
+
+        Convey("It should be valid Version 6", func() {
+            So(id.IsValid(), ShouldEqual, true)
+            So(id.Version().String(), ShouldEqual, "VERSION_6")
+        })
+
+        Convey("Converting it to V1 and back should be lossless", func() {
+            v1, err := id.ToV1()
+            So(err, ShouldBeNil)
+            So(v1.Version().String(), ShouldEqual, "VERSION_1")
+            t6, _ := id.Time()
+            t1, _ := v1.Time()
+            So(t1, ShouldEqual, t6)
+            v6, err := v1.ToV6()
+            So(err, ShouldBeNil)
+            So(kee.UUID.Match(v6, id), ShouldEqual, true)
+        })
+
+        Convey("Converting it to V6 again should fail", func() {
+            _, err := id.ToV6()
+            So(err, ShouldNotBeNil)
+        })
+
+    })
+}
+
+func TestUUIDNewV8(t *testing.T) {
+    Convey("When a V8 UUID is built from fields", t, func() {
+
+        id, err := kee.UUID.NewV8Builder().
+            Field(0xabcd, 16).
+            Field(0x7, 8).
+            Field(0xffffffffffffffff, 64).
+            Build()
+
+        Convey("No error should be returned", func() {
+            So(err, ShouldBeNil)
+        })
+
+        Convey("It should be valid Version 8", func() {
+            So(id.IsValid(), ShouldEqual, true)
+            So(id.Version().String(), ShouldEqual, "VERSION_8")
+            So(id.Variant().String(), ShouldEqual, "RFC4122")
+        })
+
+        Convey("Its fields should read back unchanged", func() {
+            shard, _ := id.V8Field(0, 16)
+            tag, _ := id.V8Field(16, 8)
+            seq, _ := id.V8Field(24, 64)
+            So(shard, ShouldEqual, uint64(0xabcd))
+            So(tag, ShouldEqual, uint64(0x7))
+            So(seq, ShouldEqual, uint64(0xffffffffffffffff))
+        })
+
+        Convey("Fields overflowing the custom bits should fail", func() {
+            _, err := kee.UUID.NewV8Builder().Field(0, 64).Field(0, 59).Build()
+            So(err, ShouldNotBeNil)
+        })
+
+    })
+}
+
+func TestUUIDNewV2(t *testing.T) {
+    Convey("When a V2 UUID is generated", t, func() {
+
+        id, err := kee.UUID.NewV2(kee.DCEGroup, 4242)
+
+        Convey("No error should be returned", func() {
+            So(err, ShouldBeNil)
+        })
+
+        Convey("It should be valid Version 2", func() {
+            So(id.IsValid(), ShouldEqual, true)
+            So(id.Version().String(), ShouldEqual, "VERSION_2")
+        })
+
+        Convey("Its domain and local id should read back unchanged", func() {
+            dom, ok := id.Domain()
+            So(ok, ShouldEqual, true)
+            So(dom, ShouldEqual, kee.DCEGroup)
+            lid, ok := id.LocalID()
+            So(ok, ShouldEqual, true)
+            So(lid, ShouldEqual, uint32(4242))
+        })
+
+        Convey("It should not report a time", func() {
+            _, ok := id.Time()
+            So(ok, ShouldEqual, false)
+        })
+
+    })
+}
+
+func TestUUIDNewBatch(t *testing.T) {
+    Convey("When a batch of V6 and V7 UUIDs is generated", t, func() {
+
+        v6, err6 := kee.UUID.NewBatch(6, 500)
+        v7, err7 := kee.UUID.NewBatch(7, 5000)
+
+        Convey("No error should be returned", func() {
+            So(err6, ShouldBeNil)
+            So(err7, ShouldBeNil)
+            So(len(v6), ShouldEqual, 500)
+            So(len(v7), ShouldEqual, 5000)
+        })
+
+        Convey("Their hex strings should strictly increase", func() {
+            for _, batch := range [][]kee.KUUID{v6, v7} {
+                ordered := true
+                for i := 1; i < len(batch); i++ {
+                    if batch[i-1].Hex() >= batch[i].Hex() { ordered = false }
+                }
+                So(ordered, ShouldEqual, true)
+            }
+        })
+
+        Convey("Unsupported versions should be rejected", func() {
+            _, err := kee.UUID.NewBatch(4, 10)
+            So(err, ShouldNotBeNil)
+        })
+
+    })
+}
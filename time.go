@@ -25,7 +25,10 @@ const (
 var (
 	mu        sync.Mutex
 	lasttime  uint64 // last time we returned
+	lastgen   uint64 // last time used for a Version 1 or 6 UUID; never reset
 	clockSeq uint16 // clock sequence for this run
+	lastms    uint64 // last Unix millisecond used for a Version 7 UUID
+	lastseq   uint16 // 12-bit counter within lastms for Version 7 UUIDs
 
 	timeNow = time.Now // for testing
 )
@@ -66,6 +69,45 @@ func getTime() (Time, error) {
 	return Time(now), nil
 }
 
+// nextTime is like getTime but never returns the same Time twice: if the clock
+// has stalled or gone backwards it returns one tick past the last Time instead.
+// It keeps its own record of the last Time, which neither GetTime nor a new
+// clock sequence resets, so Version 1 and 6 UUIDs never go back in time.
+func nextTime() Time {
+	if clockSeq == 0 {
+		setClockSequence(-1)
+	}
+	now := uint64(timeNow().UnixNano()/100) + g1582ns100
+	if now <= lastgen {
+		now = lastgen + 1
+	}
+	lastgen = now
+	if now > lasttime {
+		lasttime = now
+	}
+	return Time(now)
+}
+
+// nextV7 returns the Unix millisecond and 12-bit counter for the next Version 7
+// UUID.  Within one millisecond, or if the clock has stalled or gone
+// backwards, the counter is incremented; when it runs out the millisecond is
+// advanced instead.  The counter starts from a random value below 0x800 in
+// each new millisecond so that it rarely overflows.
+func nextV7() (uint64, uint16) {
+	now := uint64(timeNow().UnixNano() / 1e6)
+	if now > lastms {
+		var b [2]byte
+		randomBits(b[:])
+		lastms, lastseq = now, (uint16(b[0])<<8|uint16(b[1]))&0x7ff
+		return lastms, lastseq
+	}
+	lastseq++
+	if lastseq > 0xfff {
+		lastms, lastseq = lastms+1, 0
+	}
+	return lastms, lastseq
+}
+
 // ClockSequence returns the current clock sequence, generating one if not
 // already set.  The clock sequence is only used for Version 1 UUIDs.
 //
@@ -109,13 +151,27 @@ func setClockSequence(seq int) {
 }
 
 // Time returns the time in 100s of nanoseconds since 15 Oct 1582 encoded in
-// UUID.  It returns false if UUID is not valid.  The time is only well defined
-// for version 1 and 2 UUIDs.
+// UUID.  It returns false if UUID is not valid, or is Version 2, whose local
+// id replaces the low bits of the time.  The time is only well defined for
+// version 1, 6 and 7 UUIDs; Version 7 UUIDs carry millisecond precision.
 func (id KUUID) Time() (Time, bool) {
 	bytes := id.slc
 	if len(bytes) != 16 {
 		return 0, false
 	}
+	switch id.Version() {
+	case 2:
+		return 0, false
+	case 6:
+		time := int64(binary.BigEndian.Uint32(bytes[0:4])) << 28
+		time |= int64(binary.BigEndian.Uint16(bytes[4:6])) << 12
+		time |= int64(binary.BigEndian.Uint16(bytes[6:8]) & 0xfff)
+		return Time(time), true
+	case 7:
+		ms := int64(bytes[0])<<40 | int64(bytes[1])<<32 |
+			int64(binary.BigEndian.Uint32(bytes[2:6]))
+		return Time(ms*10000 + g1582ns100), true
+	}
 	time := int64(binary.BigEndian.Uint32(bytes[0:4]))
 	time |= int64(binary.BigEndian.Uint16(bytes[4:6])) << 32
 	time |= int64(binary.BigEndian.Uint16(bytes[6:8])&0xfff) << 48
@@ -123,8 +179,8 @@ func (id KUUID) Time() (Time, bool) {
 }
 
 // ClockSequence returns the clock sequence encoded in UUID.  It returns false
-// if UUID is not valid.  The clock sequence is only well defined for version 1
-// and 2 UUIDs.
+// if UUID is not valid.  The clock sequence is only well defined for version 1,
+// 2 and 6 UUIDs.
 func (id KUUID) ClockSequence() (int, bool) {	
 	bytes := id.slc
 	if len(bytes) != 16 {
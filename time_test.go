@@ -0,0 +1,53 @@
+package kee
+
+import (
+    "testing"
+    "time"
+    . "github.com/smartystreets/goconvey/convey"
+)
+
+// Drives timeNow, which the tests package can't reach, to check that time-based
+// UUIDs keep increasing when the system clock stalls or goes backwards
+func TestTimeMonotonic(t *testing.T) {
+    defer func() { timeNow = time.Now }()
+    UUID.Options.Cache = false
+
+    Convey("When the clock goes backwards between UUIDs", t, func() {
+        at := time.Now().Add(time.Hour)
+        timeNow = func() time.Time { return at }
+
+        Convey("Version 6 UUIDs should still sort in the order made", func() {
+            first, err := UUID.NewV6()
+            So(err, ShouldBeNil)
+            at = at.Add(-time.Minute)
+            GetTime() // Mustn't reset the record of the last timestamp used
+            second, err := UUID.NewV6()
+            So(err, ShouldBeNil)
+            batch, err := UUID.NewBatch(6, 3)
+            So(err, ShouldBeNil)
+            at = at.Add(-time.Minute)
+            SetClockSequence(-1)
+            third, err := UUID.NewV6()
+            So(err, ShouldBeNil)
+
+            ids := append(append([]KUUID{first, second}, batch...), third)
+            for i := 1; i < len(ids); i++ {
+                So(ids[i-1].Hex() < ids[i].Hex(), ShouldBeTrue)
+                prev, _ := ids[i-1].Time()
+                cur, _ := ids[i].Time()
+                So(prev < cur, ShouldBeTrue)
+            }
+        })
+
+        Convey("Version 1 timestamps should still increase", func() {
+            first, err := UUID.NewV1()
+            So(err, ShouldBeNil)
+            at = at.Add(-time.Minute)
+            second, err := UUID.NewV1()
+            So(err, ShouldBeNil)
+            prev, _ := first.Time()
+            cur, _ := second.Time()
+            So(prev < cur, ShouldBeTrue)
+        })
+    })
+}
@@ -3,7 +3,11 @@ package kee
 import (
     "crypto/hmac"
     "crypto/sha1"
+    "crypto/sha256"
+    "crypto/sha512"
     "encoding/base32"
+    "hash"
+    "strconv"
     "strings"
     "time"
     "regexp"
@@ -11,60 +15,82 @@ import (
     "net/url"
 )
 
+// OTPAlgorithm is the HMAC hash function one time passwords are made with
+type OTPAlgorithm string
+
+// One time password algorithms, named as in otpauth:// URIs
+const (
+    OTPSHA1   = OTPAlgorithm("SHA1")    // HMAC-SHA1, the RFC 4226 default
+    OTPSHA256 = OTPAlgorithm("SHA256")  // HMAC-SHA256
+    OTPSHA512 = OTPAlgorithm("SHA512")  // HMAC-SHA512
+)
+
 // KTOTP type represents a secret capable of producing time-based one time passwords. (RFC 6238)
 // It is exported only for reference and should be instantiated through its handler's methods.
 type KTOTP struct {
     slc []byte
     b32 string
+    opts *TOTPConfig
+    clock func() time.Time
+    alg OTPAlgorithm    // Zero values fall back on opts
+    digits, period int
 }
 
 // TOTPConfig is the struct for TOTPOptions. It should only be used if  
 // another handler with a different set of options is being created.
 type TOTPConfig struct {
     LookAhead, LookBehind, B32Blocks int
-    HyphB32 bool
+    HyphB32, WholeSecret bool
+    Algorithm OTPAlgorithm
+    Digits, Period int
+    UsedCodes UsedCodeStore
 }
 
 // TOTPOptions defines the configuration used by the `kee.TOTP` handler.
 // Options can also be changed through `kee.TOTP.Options`.
 var TOTPOptions = TOTPConfig {
-    LookAhead: 1,           // Allow passwords from n future 30-second blocks
-    LookBehind: 1,          // Allow passwords from n previous 30-second blocks
+    LookAhead: 1,           // Allow passwords from n future periods
+    LookBehind: 1,          // Allow passwords from n previous periods
     B32Blocks: 8,           // Secret length (change will invalidate stored pws)
     HyphB32: true,          // Hyphenate base 32 encoded secrets
+    WholeSecret: false,     // Keep secrets whole, ignoring B32Blocks
+    Algorithm: OTPSHA1,     // HMAC hash function: OTPSHA1, OTPSHA256 or OTPSHA512
+    Digits: 6,              // Password length, from 6 to 10
+    Period: 30,             // Seconds each password is valid for
+    UsedCodes: nil,         // Store for Verify to reject reused passwords with
 }
 
 // TOTPCtrl is a struct for the TOTP handler. 
 // Unless another handler with different options is needed simply use instance `kee.TOTP`.
 type TOTPCtrl struct {
     Options         *TOTPConfig
+    Clock           func() time.Time // Current time for MakePassword; time.Now if nil
+}
+
+// options returns the handler's config, falling back on TOTPOptions
+func (c TOTPCtrl) options() *TOTPConfig {
+    if c.Options == nil { return &TOTPOptions }
+    return c.Options
 }
 
 // New generates a new secret and returns KTOTP instance
 func (c TOTPCtrl) New() KTOTP {
     bytes := make([]byte, 32)
     randomBits(bytes)
-    return KTOTP{slc: bytes}
+    return KTOTP{slc: bytes, opts: c.options(), clock: c.Clock}
 }
 
-// Set loads an existing secret and returns KTOTP instance
+// Set loads an existing secret and returns KTOTP instance. Secrets shorter
+// than 32 bytes are zero-padded; longer ones are kept whole.
 func (c TOTPCtrl) Set(bytes []byte) KTOTP {
-    bytesSlc := make([]byte, 32)
-    copy(bytesSlc[:], bytes[:])
-    return KTOTP{slc: bytesSlc}
+    return KTOTP{slc: otpSetBytes(bytes), opts: c.options(), clock: c.Clock}
 }
 
 // Decode takes base 32 encoded string of secret and returns KTOTP instance 
 func (c TOTPCtrl) Decode(s string) (KTOTP, error) { 
-    reg, err := regexp.Compile("[^A-Za-z0-9]+")
+    s, err := otpDecode(s, otpBlocks(c.options().B32Blocks, c.options().WholeSecret))
     if err != nil { return KTOTP{}, err }
-    s = reg.ReplaceAllString(s, "")
-    s = strings.ToUpper(s)
-    if expLen := totpGetBlocks() * 4; len(s) != expLen {
-        // forgiving case, but rejecting anything less
-        return KTOTP{}, errors.New("secret length incorrect")
-    }
-    return KTOTP{b32: s}, nil // Conversion to byte value intentionally left for later
+    return KTOTP{b32: s, opts: c.options(), clock: c.Clock}, nil // Conversion to byte value intentionally left for later
 }
 
 // MatchPasswords compares expected and received secrets, return true if they match, false if not
@@ -75,6 +101,12 @@ func (c TOTPCtrl) MatchPasswords(exp []uint32, rec uint32) bool {
     return false
 }
 
+// options returns the config of the handler that produced the secret
+func (id *KTOTP) options() *TOTPConfig {
+    if id.opts == nil { return &TOTPOptions }
+    return id.opts
+}
+
 // String is alias for B32()
 func (id *KTOTP) String() string {
     return id.B32()
@@ -88,55 +120,197 @@ func (id *KTOTP) Slc() []byte {
 
 // B32 returns base 32 encoded string representation of secret
 func (id *KTOTP) B32() string {
-    var res string
-    if id.b32 != "" { res = id.b32 } else { 
-        res = base32.StdEncoding.EncodeToString(id.slc) 
-    }
-    blocks := totpGetBlocks()
-    res = res[0:blocks * 4]
-    if TOTPOptions.HyphB32 { res = hyphenate(res, 4) }
-    id.b32 = res
-    return id.b32
+    res := id.secret()
+    if id.options().HyphB32 { res = hyphenate(res, 4) }
+    return res
+}
+
+// Algorithm returns the HMAC hash function of the secret's passwords
+func (id *KTOTP) Algorithm() OTPAlgorithm {
+    if id.alg != "" { return id.alg }
+    if id.options().Algorithm != "" { return id.options().Algorithm }
+    return OTPSHA1
+}
+
+// Digits returns the number of digits of the secret's passwords
+func (id *KTOTP) Digits() int {
+    if id.digits != 0 { return id.digits }
+    if id.options().Digits != 0 { return id.options().Digits }
+    return 6
+}
+
+// Period returns the number of seconds each of the secret's passwords is valid for
+func (id *KTOTP) Period() int {
+    if id.period != 0 { return id.period }
+    if id.options().Period != 0 { return id.options().Period }
+    return 30
 }
 
 // URI returns Uniform Resource Identifier with secret for QR code generation
 func (id *KTOTP) URI(acct, issuer string) string {
-    acct = url.QueryEscape(acct)
+    acct = otpEscapeLabel(acct)
     issuer = url.QueryEscape(issuer)
-    return "otpauth://totp/"+acct+"?secret="+id.B32()+"&issuer="+issuer
+    return "otpauth://totp/"+acct+"?secret="+id.secret()+"&issuer="+issuer+
+        "&algorithm="+string(id.Algorithm())+
+        "&digits="+strconv.Itoa(id.Digits())+
+        "&period="+strconv.Itoa(id.Period())
+}
+
+// secret returns the unhyphenated base 32 secret, cut to the configured
+// number of blocks if it was generated or set from bytes
+func (id *KTOTP) secret() string {
+    if id.b32 == "" { id.b32 = otpSecret(id.slc, otpBlocks(id.options().B32Blocks, id.options().WholeSecret)) }
+    return id.b32
 }
 
 // The MIT License (MIT)
 // Copyright (c) 2014 Robbie Vanbrabant
 
-// MakePassword returns a slice of 6-digit time based passwords
+// MakePassword returns a slice of time based passwords, current one first,
+// then those of the LookBehind previous and LookAhead future periods
 func (id *KTOTP) MakePassword() ([]uint32, error) {
-    // Value must always come from B32 string and not slice directly
-    var sec string
-    if id.b32 != "" { sec = id.b32 } else { sec = id.B32() }    // critical
-    sec = strings.Replace(sec, "-", "", -1)                     // remove dashes
-    key, err := base32.StdEncoding.DecodeString(sec)
-    if err != nil {
-        return []uint32{}, errors.New("failed to make password - decoding problem")
-    }
-    epochSeconds := time.Now().Unix()
+    key, newHash, err := id.hmacParams()
+    if err != nil { return []uint32{}, err }
+    digits := id.Digits()
+    step := id.StepAt(id.now())
     pwd := []uint32{0}
 
-    pwd[0] = totpGetPassword(key, totpToBytes(epochSeconds/30))
-    for i := int64(1); i <= int64(TOTPOptions.LookBehind); i++ {
-        pwd = append(pwd, totpGetPassword(key, totpToBytes(epochSeconds/30 - i) ) )
+    pwd[0] = otpGetPassword(newHash, key, totpToBytes(step), digits)
+    for i := int64(1); i <= int64(id.options().LookBehind); i++ {
+        pwd = append(pwd, otpGetPassword(newHash, key, totpToBytes(step - i), digits))
     }
-    for i := int64(1); i <= int64(TOTPOptions.LookAhead); i++ {
-        pwd = append(pwd, totpGetPassword(key, totpToBytes(epochSeconds/30 + i) ) )
+    for i := int64(1); i <= int64(id.options().LookAhead); i++ {
+        pwd = append(pwd, otpGetPassword(newHash, key, totpToBytes(step + i), digits))
     }
     
     return pwd, nil
 }
 
+// PasswordAt returns the password of the time step at t
+func (id *KTOTP) PasswordAt(t time.Time) (uint32, error) {
+    key, newHash, err := id.hmacParams()
+    if err != nil { return 0, err }
+    return otpGetPassword(newHash, key, totpToBytes(id.StepAt(t)), id.Digits()), nil
+}
+
+// StepAt returns the time step at t, the number of whole periods since the
+// Unix epoch
+func (id *KTOTP) StepAt(t time.Time) int64 {
+    period := int64(id.Period())
+    step := t.Unix() / period
+    if t.Unix() < 0 && t.Unix() % period != 0 { step-- }
+    return step
+}
+
+// Remaining returns the time from t until the password of its time step
+// gives way to the next one
+func (id *KTOTP) Remaining(t time.Time) time.Duration {
+    return time.Unix((id.StepAt(t) + 1) * int64(id.Period()), 0).Sub(t)
+}
+
+// now returns the current time from the clock of the handler that produced
+// the secret
+func (id *KTOTP) now() time.Time {
+    if id.clock == nil { return time.Now() }
+    return id.clock()
+}
+
+// Verify checks code against the password of the time step at and those of
+// the LookBehind previous and LookAhead future steps. It returns the step that
+// matched and its drift, the number of steps it is off at by, or
+// ErrPasswordMismatch. If the UsedCodes option is set, a code is accepted
+// only once: it is rejected with ErrPasswordReused unless its step is after
+// the last one accepted for the secret.
+func (id *KTOTP) Verify(code uint32, at time.Time) (step int64, drift int, err error) {
+    key, newHash, err := id.hmacParams()
+    if err != nil { return 0, 0, err }
+    now := id.StepAt(at)
+    drifts := []int{0}
+    for i := 1; i <= id.options().LookBehind; i++ { drifts = append(drifts, -i) }
+    for i := 1; i <= id.options().LookAhead; i++ { drifts = append(drifts, i) }
+    for _, drift := range drifts {
+        step := now + int64(drift)
+        if otpGetPassword(newHash, key, totpToBytes(step), id.Digits()) != code { continue }
+        if store := id.options().UsedCodes; store != nil {
+            ok, err := store.MarkUsed(otpStoreKey(key), step)
+            if err != nil { return 0, 0, err }
+            if !ok { return step, drift, ErrPasswordReused }
+        }
+        return step, drift, nil
+    }
+    return 0, 0, ErrPasswordMismatch
+}
+
+// hmacParams returns the HMAC key and hash function of the secret's
+// passwords, or an error if its options can't make any
+func (id *KTOTP) hmacParams() ([]byte, func() hash.Hash, error) {
+    key, err := otpKey(id.secret())
+    if err != nil { return nil, nil, err }
+    newHash, err := otpHash(id.Algorithm(), id.Digits())
+    if err != nil { return nil, nil, err }
+    if id.Period() < 0 { return nil, nil, errors.New("password period must be positive") }
+    return key, newHash, nil
+}
+
 // --- Helpers ---
 
-func totpGetBlocks() int {
-    blocks := TOTPOptions.B32Blocks
+// otpSetBytes returns a copy of a secret, zero-padded to at least 32 bytes
+func otpSetBytes(bytes []byte) []byte {
+    size := 32
+    if len(bytes) > size { size = len(bytes) }
+    bytesSlc := make([]byte, size)
+    copy(bytesSlc[:], bytes[:])
+    return bytesSlc
+}
+
+// otpDecode strips a base 32 secret of anything but letters and digits and
+// checks it has blocks blocks, unless blocks is 0
+func otpDecode(s string, blocks int) (string, error) {
+    reg, err := regexp.Compile("[^A-Za-z0-9]+")
+    if err != nil { return "", err }
+    s = reg.ReplaceAllString(s, "")
+    s = strings.ToUpper(s)
+    if expLen := blocks * 4; expLen > 0 && len(s) != expLen {
+        // forgiving case, but rejecting anything less
+        return "", errors.New("secret length incorrect")
+    }
+    return s, nil
+}
+
+// otpSecret returns the unpadded base 32 encoding of slc, cut to blocks
+// blocks unless blocks is 0
+func otpSecret(slc []byte, blocks int) string {
+    res := strings.TrimRight(base32.StdEncoding.EncodeToString(slc), "=")
+    if blocks > 0 && blocks * 4 < len(res) {
+        res = res[0:blocks * 4]
+    }
+    return res
+}
+
+// otpKey returns the HMAC key. Value must always come from B32 string and not
+// slice directly, as the string may be cut to fewer bytes.
+func otpKey(b32 string) ([]byte, error) {
+    key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(b32)
+    switch len(b32) % 8 { // lengths no number of bytes encodes to
+    case 1, 3, 6:
+        err = errors.New("impossible base 32 length")
+    }
+    if err != nil || len(key) == 0 {
+        return []byte{}, errors.New("failed to make password - decoding problem")
+    }
+    return key, nil
+}
+
+// otpBlocks returns the number of blocks secrets are cut to under the
+// B32Blocks and WholeSecret options, or 0 to keep them whole
+func otpBlocks(blocks int, whole bool) int {
+    if whole { return 0 }
+    return otpGetBlocks(blocks)
+}
+
+// otpGetBlocks returns the number of 4-character blocks secrets are cut
+// to, from 4 to 13
+func otpGetBlocks(blocks int) int {
     switch {
     case(blocks > 13):
         blocks = 13
@@ -146,6 +320,21 @@ func totpGetBlocks() int {
     return blocks
 }
 
+// otpHash returns the hash function of the named algorithm, or an error if
+// it is unknown or passwords of that many digits can't be made
+func otpHash(alg OTPAlgorithm, digits int) (func() hash.Hash, error) {
+    if digits < 6 || digits > 10 { return nil, errors.New("password must have 6 to 10 digits") }
+    switch alg {
+    case OTPSHA1:
+        return sha1.New, nil
+    case OTPSHA256:
+        return sha256.New, nil
+    case OTPSHA512:
+        return sha512.New, nil
+    }
+    return nil, errors.New("unknown password algorithm " + string(alg))
+}
+
 func totpToBytes(value int64) []byte {
     var result []byte
     mask := int64(0xFF)
@@ -161,30 +350,34 @@ func totpToUint32(bytes []byte) uint32 {
         (uint32(bytes[2]) << 8) + uint32(bytes[3])
 }
 
-func totpGetPassword(key []byte, value []byte) uint32 {
-    // sign the value using HMAC-SHA1
-    hmacSha1 := hmac.New(sha1.New, key)
-    hmacSha1.Write(value)
-    hash := hmacSha1.Sum(nil)
+// otpGetPassword returns the HOTP value (RFC 4226) of the 8-byte counter value
+func otpGetPassword(newHash func() hash.Hash, key []byte, value []byte, digits int) uint32 {
+    // sign the value using HMAC with the chosen hash
+    mac := hmac.New(newHash, key)
+    mac.Write(value)
+    sum := mac.Sum(nil)
 
     // We're going to use a subset of the generated hash.
     // Using the last nibble (half-byte) to choose the index to start from.
     // This number is always appropriate as it's maximum decimal 15, the hash will
-    // have the maximum index 19 (20 bytes of SHA1) and we need 4 bytes.
-    offset := hash[len(hash)-1] & 0x0F
+    // have at least the maximum index 19 (20 bytes of SHA1) and we need 4 bytes.
+    offset := sum[len(sum)-1] & 0x0F
 
     // get a 32-bit (4-byte) chunk from the hash starting at offset
-    hashParts := hash[offset : offset+4]
+    hashParts := sum[offset : offset+4]
 
     // ignore the most significant bit as per RFC 4226
     hashParts[0] = hashParts[0] & 0x7F
 
     number := totpToUint32(hashParts)
 
-    // size to 6 digits
-    // one million is the first number with 7 digits so the remainder
-    // of the division will always return < 7 digits
-    pwd := number % 1000000
+    // size to the number of digits
+    // 10^digits is the first number with one digit more so the remainder
+    // of the division will always be short enough; the number itself is
+    // under 2^31, so 10 digits need no division at all
+    mod := uint64(1)
+    for i := 0; i < digits; i++ { mod *= 10 }
+    pwd := uint32(uint64(number) % mod)
 
     return pwd
 }
@@ -7,7 +7,7 @@ import(
     "strings"
     "io"
     "math/big"
-    "strconv"
+    "sync"
     "time"
 )
 
@@ -32,6 +32,32 @@ func url64ToB64(s string) string {
     return s
 }
 
+// encCache holds the encoded strings of an ID. It is shared by all copies of
+// the ID it was created for and is safe for concurrent use.
+type encCache struct {
+    mu sync.RWMutex
+    strs map[string]string
+}
+
+func newEncCache() *encCache {
+    return &encCache{strs: make(map[string]string)}
+}
+
+// get returns the string stored under key. If there is none, it is produced
+// by enc and, if enabled, stored. A nil cache never stores anything.
+func (c *encCache) get(enabled bool, key string, enc func() string) string {
+    if c == nil || !enabled { return enc() }
+    c.mu.RLock()
+    s, ok := c.strs[key]
+    c.mu.RUnlock()
+    if ok { return s }
+    s = enc()
+    c.mu.Lock()
+    c.strs[key] = s
+    c.mu.Unlock()
+    return s
+}
+
 // Inserts a dash every n characters
 func hyphenate(s string, n int) string {
     os := strings.Split(s, "")
@@ -60,6 +86,14 @@ func fromHexChar(c byte) (byte, bool) {
     return 0, false
 }
 
+// isHex reports whether s is made only of hex characters
+func isHex(s string) bool {
+    for i := 0; i < len(s); i++ {
+        if _, ok := fromHexChar(s[i]); !ok { return false }
+    }
+    return true
+}
+
 func fromHexOctet(s string) (byte, bool) {
     a, ok := fromHexChar(s[0])
     if !ok {
@@ -97,65 +131,67 @@ func randomBits(b []byte) {
     }
 }
 
-// Copyright (c) 2012 Tommi Virtanen
-
-// Package base58 implements a human-friendly base58 encoding.
-//
-// As opposed to base64 and friends, base58 is typically used to
-// convert integers. You can use big.Int.SetBytes to convert arbitrary
-// bytes to an integer first, and big.Int.Bytes the other way around.
-
-const b58Alphabet = "123456789abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
-
-var b58DecodeMap [256]byte
-
-func init() {
-    for i := 0; i < len(b58DecodeMap); i++ {
-        b58DecodeMap[i] = 0xFF
-    }
-    for i := 0; i < len(b58Alphabet); i++ {
-        b58DecodeMap[b58Alphabet[i]] = byte(i)
-    }
-}
-
-type b58CorruptInputError int64
+// -- Base 62 / base 36 --
 
-func (e b58CorruptInputError) Error() string {
-    return "illegal base58 data at input byte " + strconv.FormatInt(int64(e), 10)
-}
+// Digits in ASCII order, so fixed-width strings sort like the numbers they encode
+const (
+    b62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+    b36Alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
 
-// Decode a big integer from the bytes. Returns an error on corrupt input.
-func b58ToBigInt(src []byte) (*big.Int, error) {
-    n := new(big.Int)
-    radix := big.NewInt(58)
-    for i := 0; i < len(src); i++ {
-        b := b58DecodeMap[src[i]]
-        if b == 0xFF {
-            return nil, b58CorruptInputError(i)
-        }
-        n.Mul(n, radix)
-        n.Add(n, big.NewInt(int64(b)))
+// radixWidth returns the number of digits in base radix needed to hold any
+// value of size bytes
+func radixWidth(size int, radix int64) int {
+    max := new(big.Int).Lsh(big.NewInt(1), uint(size * 8))
+    n, r := big.NewInt(1), big.NewInt(radix)
+    w := 0
+    for n.Cmp(max) < 0 {
+        n.Mul(n, r)
+        w++
     }
-    return n, nil
+    return w
 }
 
-// Encode encodes src, appending to dst. Be sure to use the returned
-// new value of dst.
-func bigIntToB58(dst []byte, src *big.Int) []byte {
-    start := len(dst)
-    n := new(big.Int)
-    n.Set(src)
-    radix := big.NewInt(58)
+// radixEncode returns big-endian src as a number in the base of alphabet,
+// left-padded with zero digits to width
+func radixEncode(src []byte, alphabet string, width int) string {
+    n := new(big.Int).SetBytes(src)
+    radix := big.NewInt(int64(len(alphabet)))
     zero := big.NewInt(0)
-
+    var res []byte
     for n.Cmp(zero) > 0 {
         mod := new(big.Int)
         n.DivMod(n, radix, mod)
-        dst = append(dst, b58Alphabet[mod.Int64()])
+        res = append(res, alphabet[mod.Int64()])
+    }
+    for len(res) < width {
+        res = append(res, alphabet[0])
     }
+    for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+        res[i], res[j] = res[j], res[i]
+    }
+    return string(res)
+}
 
-    for i, j := start, len(dst)-1; i < j; i, j = i+1, j-1 {
-        dst[i], dst[j] = dst[j], dst[i]
+// radixDecode decodes s, a number in the base of alphabet, into size
+// big-endian bytes, or into as few bytes as needed if size is 0
+func radixDecode(s string, alphabet string, size int) ([]byte, error) {
+    if len(s) == 0 {
+        return []byte{}, newParseError(ParseBadLength, -1)
+    }
+    n := new(big.Int)
+    radix := big.NewInt(int64(len(alphabet)))
+    for i := 0; i < len(s); i++ {
+        d := strings.IndexByte(alphabet, s[i])
+        if d < 0 {
+            return []byte{}, newParseError(ParseIllegalChar, i)
+        }
+        n.Mul(n, radix)
+        n.Add(n, big.NewInt(int64(d)))
     }
-    return dst
-}
\ No newline at end of file
+    if size == 0 { return n.Bytes(), nil }
+    if n.BitLen() > size * 8 {
+        return []byte{}, newParseError(ParseBadLength, -1)
+    }
+    return n.FillBytes(make([]byte, size)), nil
+}
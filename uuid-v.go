@@ -9,6 +9,7 @@ import(
     "crypto/md5"
     "crypto/sha1"
     "errors"
+    "fmt"
     "hash"
 )
 
@@ -16,37 +17,78 @@ import(
 // sequence, and the current time.  If the NodeID has not been set by SetNodeID
 // or SetNodeInterface then it will be set automatically.  If the NodeID cannot
 // be set NewUUID returns nil.  If clock sequence has not been set by
-// SetClockSequence then it will be set automatically.  If GetTime fails to
-// return the current NewUUID returns nil.
+// SetClockSequence then it will be set automatically.  Timestamps strictly
+// increase, even if the system clock stalls or goes backwards, as in NewBatch.
 func (c UUIDCtrl) NewV1() (KUUID, error) {
     if node.nodeID == nil {
         c.SetNodeInterface("")
     }
 
-    now, err := GetTime()
-    if err != nil {
-        return c.newInst([]byte{}, err)
-    }
-
     bytes := make([]byte, 16)
-
-    timeLow := uint32(now & 0xffffffff)
-    timeMid := uint16((now >> 32) & 0xffff)
-    timeHi := uint16((now >> 48) & 0x0fff)
-    timeHi |= 0x1000 // Version 1
-
-    binary.BigEndian.PutUint32(bytes[0:], timeLow)
-    binary.BigEndian.PutUint16(bytes[4:], timeMid)
-    binary.BigEndian.PutUint16(bytes[6:], timeHi)
+    mu.Lock()
+    putV1Time(bytes, uint64(nextTime()))
     binary.BigEndian.PutUint16(bytes[8:], clockSeq)
+    mu.Unlock()
     copy(bytes[10:], node.nodeID)
 
     return c.newInst(bytes, nil)
 }
 
-// NewV2 is highly performant; may need some refactoring
-func (c UUIDCtrl) NewV2() (KUUID, error) {
-    return KUUID{}, errors.New("no")
+// NewV2 returns a DCE Security (Version 2) UUID, as in DCE 1.1, based on the
+// current NodeID, clock sequence and time. The time_low field is replaced by
+// the local id (e.g. a POSIX UID or GID) and the low byte of the clock
+// sequence by the domain, so at most one such UUID per domain and id can be
+// created every 7 minutes or so. Use only if a legacy system demands it.
+func (c UUIDCtrl) NewV2(domain UUIDDomain, id uint32) (KUUID, error) {
+    res, err := c.NewV1()
+    if err != nil || len(res.slc) != 16 {
+        return res, err
+    }
+    bytes := res.slc
+    binary.BigEndian.PutUint32(bytes[0:], id)
+    bytes[6] = (bytes[6] & 0x0f) | 0x20
+    bytes[9] = byte(domain)
+    return c.newInst(bytes, nil)
+}
+
+// Domain returns the DCE domain encoded in a Version 2 UUID. It returns false
+// if the UUID is not Version 2.
+func (id KUUID) Domain() (UUIDDomain, bool) {
+    if id.Version() != 2 {
+        return 0, false
+    }
+    return UUIDDomain(id.slc[9]), true
+}
+
+// LocalID returns the local id (e.g. a POSIX UID or GID) encoded in a Version
+// 2 UUID. It returns false if the UUID is not Version 2.
+func (id KUUID) LocalID() (uint32, bool) {
+    if id.Version() != 2 {
+        return 0, false
+    }
+    return binary.BigEndian.Uint32(id.slc[0:4]), true
+}
+
+// A UUIDDomain represents the DCE domain of a Version 2 UUID.
+type UUIDDomain byte
+
+// Domains defined by DCE 1.1; other values are site-defined.
+const (
+    DCEPerson = UUIDDomain(0) // POSIX UID
+    DCEGroup  = UUIDDomain(1) // POSIX GID
+    DCEOrg    = UUIDDomain(2) // Organization
+)
+
+func (d UUIDDomain) String() string {
+    switch d {
+    case DCEPerson:
+        return "Person"
+    case DCEGroup:
+        return "Group"
+    case DCEOrg:
+        return "Org"
+    }
+    return fmt.Sprintf("Domain%d", int(d))
 }
 
 // NewV3 returns a new MD5 (Version 3) UUID based on the
@@ -84,6 +126,172 @@ func (c UUIDCtrl) NewV5(id KUUID, data []byte) (KUUID, error) {
     return c.newInst(c.newHash(sha1.New(), space, data, 5), nil)
 }
 
+// NewV6 returns a reordered Gregorian time-based (Version 6) UUID, as in
+// RFC 9562. It carries the same timestamp, clock sequence and NodeID as a
+// Version 1 UUID but stores the timestamp most significant bits first, so
+// UUIDs sort in the order they were created, even if the system clock stalls
+// or goes backwards, and with those made by NewBatch.
+func (c UUIDCtrl) NewV6() (KUUID, error) {
+    if node.nodeID == nil {
+        c.SetNodeInterface("")
+    }
+
+    bytes := make([]byte, 16)
+    mu.Lock()
+    putV6Time(bytes, uint64(nextTime()))
+    binary.BigEndian.PutUint16(bytes[8:], clockSeq)
+    mu.Unlock()
+    copy(bytes[10:], node.nodeID)
+
+    return c.newInst(bytes, nil)
+}
+
+// NewV7 returns a Unix Epoch time-based (Version 7) UUID, as in RFC 9562.
+// The first 48 bits hold the number of milliseconds since 1 Jan 1970, the
+// next 12 bits a counter and the remaining 62 bits are random, so UUIDs sort
+// in the order they were created within this process.
+func (c UUIDCtrl) NewV7() (KUUID, error) {
+    mu.Lock()
+    ms, seq := nextV7()
+    mu.Unlock()
+    return c.newInst(makeV7(ms, seq), nil)
+}
+
+// NewBatch returns n unique Version 1, 6 or 7 UUIDs, generated under a single
+// lock. Their timestamps (and, for Version 7, counters) strictly increase, even
+// if the system clock stalls or goes backwards, so Version 6 and 7 UUIDs
+// in the batch sort in the order returned and after any created before, singly
+// or in batches.
+func (c UUIDCtrl) NewBatch(version, n int) ([]KUUID, error) {
+    if n < 0 {
+        return nil, errors.New("negative UUID batch size")
+    }
+    if version != 1 && version != 6 && version != 7 {
+        return nil, errors.New("batches only support Version 1, 6 and 7 UUIDs")
+    }
+    if version != 7 && node.nodeID == nil {
+        c.SetNodeInterface("")
+    }
+
+    raw := make([][]byte, n)
+    mu.Lock()
+    for i := range raw {
+        if version == 7 {
+            raw[i] = makeV7(nextV7())
+            continue
+        }
+        bytes := make([]byte, 16)
+        if version == 1 {
+            putV1Time(bytes, uint64(nextTime()))
+        } else {
+            putV6Time(bytes, uint64(nextTime()))
+        }
+        binary.BigEndian.PutUint16(bytes[8:], clockSeq)
+        copy(bytes[10:], node.nodeID)
+        raw[i] = bytes
+    }
+    mu.Unlock()
+
+    res := make([]KUUID, n)
+    for i, bytes := range raw {
+        id, err := c.newInst(bytes, nil)
+        if err != nil {
+            return nil, err
+        }
+        res[i] = id
+    }
+    return res, nil
+}
+
+// NewV8 returns a custom (Version 8) UUID, as in RFC 9562, built from payload.
+// The version and variant bits of payload are overwritten; the other 122 bits
+// are kept as they are.
+func (c UUIDCtrl) NewV8(payload [16]byte) (KUUID, error) {
+    bytes := make([]byte, 16)
+    copy(bytes, payload[:])
+    bytes[6] = (bytes[6] & 0x0f) | 0x80
+    bytes[8] = (bytes[8] & 0x3f) | 0x80
+    return c.newInst(bytes, nil)
+}
+
+// NewV8Builder returns a builder for packing fields into the 122 custom bits
+// of a Version 8 UUID.
+func (c UUIDCtrl) NewV8Builder() *UUIDV8Builder {
+    return &UUIDV8Builder{ctrl: c}
+}
+
+// UUIDV8Builder packs integer fields, most significant first, into the custom
+// bits of a Version 8 UUID, skipping over the version and variant bits.
+// It should be instantiated through `kee.UUID.NewV8Builder`.
+type UUIDV8Builder struct {
+    ctrl UUIDCtrl
+    arr [16]byte
+    used uint
+    err error
+}
+
+// Field appends the lowest width bits of val to the UUID. Errors are deferred
+// until Build so calls can be chained.
+func (b *UUIDV8Builder) Field(val uint64, width uint) *UUIDV8Builder {
+    if b.err != nil { return b }
+    switch {
+    case width == 0 || width > 64:
+        b.err = errors.New("V8 field width must be between 1 and 64 bits")
+    case width < 64 && val >> width != 0:
+        b.err = errors.New("V8 field value does not fit in its width")
+    case b.used + width > uuidV8Bits:
+        b.err = errors.New("V8 fields exceed 122 custom bits")
+    }
+    if b.err != nil { return b }
+    for i := int(width) - 1; i >= 0; i-- {
+        if val & (1 << uint(i)) != 0 {
+            pos := v8BitPos(b.used)
+            b.arr[pos/8] |= 0x80 >> (pos % 8)
+        }
+        b.used++
+    }
+    return b
+}
+
+// Build returns the packed Version 8 UUID; unused custom bits are left zero.
+func (b *UUIDV8Builder) Build() (KUUID, error) {
+    if b.err != nil { return KUUID{}, b.err }
+    return b.ctrl.NewV8(b.arr)
+}
+
+// V8Field reads width bits starting at custom bit offset of a Version 8 UUID;
+// it is the counterpart of UUIDV8Builder.Field.
+func (id KUUID) V8Field(offset, width uint) (uint64, error) {
+    if id.Version() != 8 {
+        return 0, errors.New("UUID is not Version 8")
+    }
+    if width == 0 || width > 64 || offset + width > uuidV8Bits {
+        return 0, errors.New("V8 field out of range")
+    }
+    var val uint64
+    for i := offset; i < offset + width; i++ {
+        pos := v8BitPos(i)
+        val <<= 1
+        if id.slc[pos/8] & (0x80 >> (pos % 8)) != 0 { val |= 1 }
+    }
+    return val, nil
+}
+
+const uuidV8Bits = 122 // custom bits available in a Version 8 UUID
+
+// v8BitPos maps custom bit n to its position in the UUID, counting from the
+// most significant bit, around the 4 version bits (48-51) and the 2 variant
+// bits (64-65).
+func v8BitPos(n uint) uint {
+    switch {
+    case n < 48:
+        return n
+    case n < 60:
+        return n + 4
+    }
+    return n + 6
+}
+
 // newHash returns a new UUID dervied from the hash of space concatenated with
 // data generated by h.  The hash should be at least 16 byte in length.  The
 // first 16 bytes of the hash are used to form the UUID.  The version of the
@@ -100,3 +308,60 @@ func (_ UUIDCtrl) newHash(h hash.Hash, space []byte, data []byte, version int) [
     bytes[8] = (bytes[8] & 0x3f) | 0x80 // RFC 4122 variant
     return bytes
 }
+
+// ToV6 converts a Version 1 UUID to its Version 6 equivalent. The timestamp,
+// clock sequence and NodeID are kept intact so the conversion can be reversed
+// with ToV1. An error is returned if the UUID is not Version 1.
+func (id KUUID) ToV6() (KUUID, error) {
+    if id.Version() != 1 {
+        return KUUID{}, errors.New("UUID is not Version 1")
+    }
+    ts, _ := id.Time()
+    bytes := make([]byte, 16)
+    copy(bytes, id.slc)
+    putV6Time(bytes, uint64(ts))
+    return KUUID{slc: bytes, opts: id.opts, cache: newEncCache()}, nil
+}
+
+// ToV1 converts a Version 6 UUID back to its Version 1 equivalent for
+// consumers that only understand the original layout. An error is returned if
+// the UUID is not Version 6.
+func (id KUUID) ToV1() (KUUID, error) {
+    if id.Version() != 6 {
+        return KUUID{}, errors.New("UUID is not Version 6")
+    }
+    ts, _ := id.Time()
+    bytes := make([]byte, 16)
+    copy(bytes, id.slc)
+    putV1Time(bytes, uint64(ts))
+    return KUUID{slc: bytes, opts: id.opts, cache: newEncCache()}, nil
+}
+
+// makeV7 returns the bytes of a Version 7 UUID with Unix millisecond ms and
+// 12-bit counter seq, filling the remaining bits with random data.
+func makeV7(ms uint64, seq uint16) []byte {
+    bytes := make([]byte, 16)
+    randomBits(bytes[8:])
+    bytes[0] = byte(ms >> 40)
+    bytes[1] = byte(ms >> 32)
+    binary.BigEndian.PutUint32(bytes[2:], uint32(ms))
+    binary.BigEndian.PutUint16(bytes[6:], (seq & 0x0fff) | 0x7000)
+    bytes[8] = (bytes[8] & 0x3f) | 0x80
+    return bytes
+}
+
+// putV1Time writes 60-bit timestamp ts and the version nibble to the first
+// 8 bytes of a Version 1 UUID (time_low, time_mid, time_hi_and_version).
+func putV1Time(bytes []byte, ts uint64) {
+    binary.BigEndian.PutUint32(bytes[0:], uint32(ts & 0xffffffff))
+    binary.BigEndian.PutUint16(bytes[4:], uint16((ts >> 32) & 0xffff))
+    binary.BigEndian.PutUint16(bytes[6:], uint16((ts >> 48) & 0x0fff) | 0x1000)
+}
+
+// putV6Time writes 60-bit timestamp ts and the version nibble to the first
+// 8 bytes of a Version 6 UUID (time_high, time_mid, time_low_and_version).
+func putV6Time(bytes []byte, ts uint64) {
+    binary.BigEndian.PutUint32(bytes[0:], uint32(ts >> 28))
+    binary.BigEndian.PutUint16(bytes[4:], uint16((ts >> 12) & 0xffff))
+    binary.BigEndian.PutUint16(bytes[6:], uint16(ts & 0x0fff) | 0x6000)
+}
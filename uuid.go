@@ -1,9 +1,7 @@
 package kee
 
 import(
-    "encoding/base64"
-    "encoding/base32"
-    "encoding/ascii85"
+    "encoding/json"
     "strings"
     "errors"
     "fmt"
@@ -14,13 +12,8 @@ import(
 // It is exported only for reference and should be instantiated through its handler's methods.
 type KUUID struct {
     slc []byte
-    hex string
-    a85 string
-    b64 string
-    b32 string
-    urn string
-    url64 string
-    url32 string
+    opts *UUIDConfig
+    cache *encCache
 }
 
 // UUIDConfig is the struct for UUIDOptions. It should only be used if  
@@ -29,6 +22,9 @@ type UUIDConfig struct {
     Cache, AllowInvalid bool
     MinVer, MaxVer uint8 
     PadB64, PadB32, WrapA85, HyphURL32 bool
+    CrockfordCheck bool
+    TextFormat string
+    SQLText bool
 }
 
 // UUIDOptions defines the configuration used by the `kee.UUID` handler.
@@ -37,11 +33,14 @@ var UUIDOptions = UUIDConfig {
     Cache: true,            // Cache UUID strings, ignore new options
     AllowInvalid: false,    // Allows setting of non-standard UUIDs
     MinVer: 1,              // Lowest UUID version allowed as valid
-    MaxVer: 5,              // Highest UUID version allowed as valid
+    MaxVer: 8,              // Highest UUID version allowed as valid
     PadB64: true,           // Add padding to base 64 encoded UUIDs
     PadB32: true,           // Add padding to base 32 encoded UUIDs
     WrapA85: false,         // Wrap ASCII 85 encoded UUIDs with <~ ~>
     HyphURL32: true,        // Hyphenate base 32 encoded URL UUIDs
+    CrockfordCheck: true,   // Append check symbol to Crockford base 32 UUIDs
+    TextFormat: "hex",      // Text/JSON form: name of any UUID encoding
+    SQLText: false,         // Store UUIDs in SQL as hex text instead of 16 bytes
 }
 
 // UUIDCtrl is a struct for the UUID handler. 
@@ -51,21 +50,30 @@ type UUIDCtrl struct {
     NS map[string]string    // Namespaces
 }
 
+// errNilUUID is returned along with the nil UUID, which is invalid but usable
+var errNilUUID = errors.New("nil UUID set")
+
 func (c UUIDCtrl) newInst(bytes []byte, err error) (KUUID, error) {
-    res := KUUID{slc: bytes}
+    res := KUUID{slc: bytes, opts: c.options(), cache: newEncCache()}
     if err != nil { // A parsing or other unrecoverable error occured
         return KUUID{}, err
     }
-    if !UUIDOptions.AllowInvalid && !res.IsValid() { 
+    if !res.opts.AllowInvalid && !res.IsValid() { 
         if len(res.slc) > 0 && res.Arr() == [16]byte{} { 
             // Allow NIL UUID but return error if no override
-            return res, errors.New("nil UUID set")
+            return res, errNilUUID
         } 
         return KUUID{}, errors.New("invalid UUID")
     }
     return res, nil
 }
 
+// options returns the handler's config, falling back on UUIDOptions
+func (c UUIDCtrl) options() *UUIDConfig {
+    if c.Options == nil { return &UUIDOptions }
+    return c.Options
+}
+
 // New is alias for NewV4; returns random Version 4 UUID and as KUUID instance
 func (c UUIDCtrl) New() KUUID {
     res, _ := c.NewV4() // swallows errors but none should occur
@@ -80,29 +88,66 @@ func (c UUIDCtrl) Set(arr [16]byte) KUUID {
     return res
 }
 
-// Decode takes encoded string of UUID and returns KUUID instance
+// SetGUIDBytes takes 16 bytes in the mixed-endian layout of Microsoft's GUID
+// struct, as returned by GUIDBytes, and returns KUUID instance
+func (c UUIDCtrl) SetGUIDBytes(slc []byte) (KUUID, error) {
+    if len(slc) != 16 {
+        return KUUID{}, errors.New("binary GUID must be 16 bytes")
+    }
+    return c.newInst(guidSwap(slc), nil)
+}
+
+// Decode takes encoded string of UUID and returns KUUID instance. The encoding
+// is guessed from the length of the string; use DecodeAs where lengths are shared,
+// as for base 62 and base 58, which Decode reads as base 64 without complaint.
+// Hex is accepted with or without hyphens, in braces or as URN, in any case.
+// Crockford base 32 is recognized only with its check symbol.
 func (c UUIDCtrl) Decode(s string) (KUUID, error) {
-    var bytes []byte
-    var err error
+    var format string
     switch len(s) {
     case 20: 
-        bytes, err = c.fromA85(s)
+        format = "a85"
     case 22:
-        bytes, err = c.fromB64(s)
+        format = "b64"
     case 24:
         if(s[:2] == "<~" && s[22:] == "~>") {
-            bytes, err = c.fromA85(s)
+            format = "a85"
         } else {
-            bytes, err = c.fromB64(s)
+            format = "b64"
         }
     case 26, 26+6: 
-        bytes, err = c.fromB32(s)
-    case 36, 36+9:
-        bytes, err = c.fromHex(s)
+        if len(s) == 32 && isHex(s) {
+            format = "hex" // unhyphenated
+        } else {
+            format = "b32" // Crockford only with check symbol, which can't collide
+        }
+    case 27:
+        format = "crockford"
+    case 32+2, 36, 36+2, 32+9, 36+9: // braced, hyphenated or URN
+        format = "hex"
     default:
-        return c.newInst([]byte{}, errors.New("unrecognized UUID encoding"))
+        if n := len(crockfordNormalize(s)); n == 26 || n == 27 {
+            format = "crockford"
+            break
+        }
+        return c.newInst([]byte{}, &ParseError{"", -1, ParseBadLength, nil})
+    }
+    return c.DecodeAs(format, s)
+}
+
+// DecodeAs takes a UUID encoded with the named encoding and returns KUUID
+// instance. Built-in encodings are "hex", "urn", "a85", "b64", "url64", "b32",
+// "url32", "crockford", "b62", "b36" and "b58"; see RegisterEncoding for others.
+func (c UUIDCtrl) DecodeAs(format, s string) (KUUID, error) {
+    enc, ok := uuidEncoding(c.options(), format)
+    if !ok {
+        return c.newInst([]byte{}, &ParseError{format, -1, ParseUnknownFormat, nil})
     }
-    return c.newInst(bytes, err)
+    bytes, err := enc.DecodeString(s, 16)
+    if err != nil { return c.newInst([]byte{}, parseError(format, err)) }
+    res, err := c.newInst(bytes, nil)
+    if err != nil { err = &ParseError{format, -1, ParseInvalidVersion, err} }
+    return res, err
 }
 
 // Match takes two KUUID instances; returns `true` if they are identical or false if not
@@ -114,12 +159,24 @@ func (_ UUIDCtrl) Match(ida, idb KUUID) bool {
 func (id KUUID) IsValid() (valid bool) {
     if len(id.slc) != 16 { return false }
     ver := id.Version()
-    if uint8(ver) < UUIDOptions.MinVer || uint8(ver) > UUIDOptions.MaxVer { 
+    opts := id.options()
+    if uint8(ver) < opts.MinVer || uint8(ver) > opts.MaxVer { 
         return false 
     }
     return true
 }
 
+// options returns the config of the handler that produced the UUID
+func (id KUUID) options() *UUIDConfig {
+    if id.opts == nil { return &UUIDOptions }
+    return id.opts
+}
+
+// handler returns a handler sharing the config of the one that produced the UUID
+func (id KUUID) handler() UUIDCtrl {
+    return UUIDCtrl{id.options(), UUID.NS}
+}
+
 // -- Produce --
 
 // String is alias for Hex
@@ -138,118 +195,222 @@ func (id KUUID) Arr() (res [16]byte) {
     return 
 }
 
+// GUIDBytes returns UUID in the mixed-endian layout of Microsoft's GUID struct,
+// with the first three fields little-endian, as used by .NET and COM
+func (id KUUID) GUIDBytes() []byte {
+    if len(id.slc) != 16 { return []byte{} }
+    return guidSwap(id.slc)
+}
+
+// Encode returns string representation of UUID in the named encoding, which
+// may be any of those DecodeAs accepts
+func (id KUUID) Encode(name string) (string, error) {
+    enc, ok := uuidEncoding(id.options(), name)
+    if !ok { return "", errors.New("unknown UUID encoding " + name) }
+    if id.slc == nil || len(id.slc) == 0    { return "", nil }
+    return id.cached(name, func() string {
+        return enc.EncodeToString(id.slc)
+    }), nil
+}
+
 // Hex returns canonical hex string representation of UUID, as in RFC 4122
-func (id *KUUID) Hex() string {
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if UUIDOptions.Cache && id.hex != ""    { return id.hex }
-    u := id.slc
-    id.hex = fmt.Sprintf(
-        "%08x-%04x-%04x-%04x-%012x",
-        u[:4], u[4:6], u[6:8], u[8:10], u[10:])
-    return id.hex
+func (id KUUID) Hex() string {
+    res, _ := id.Encode("hex")
+    return res
 }
 
 // A85 returns ASCII 85 encoded string representation of UUID
-func (id *KUUID) A85() string {
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if UUIDOptions.Cache && id.a85 != ""    { return id.a85 }
-    bytes := make([]byte, 20)
-    ascii85.Encode(bytes, id.slc)
-    if UUIDOptions.WrapA85 {
-        parts := []string{"<~", string(bytes[:]), "~>"}
-        id.a85 = strings.Join(parts, "")
-    } else { id.a85 = string(bytes) }    
-    return id.a85
+func (id KUUID) A85() string {
+    res, _ := id.Encode("a85")
+    return res
 }
+
 // B64 returns base 64 encoded string representation of UUID
-func (id *KUUID) B64() string {
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if UUIDOptions.Cache && id.b64 != ""    { return id.b64 }
-    res := base64.StdEncoding.EncodeToString(id.slc)
-    if !UUIDOptions.PadB64 { res = res[0:22] }
-    id.b64 = res
-    return id.b64
+func (id KUUID) B64() string {
+    res, _ := id.Encode("b64")
+    return res
 }
 
 // B32 returns base 32 encoded string representation of UUID
-func (id *KUUID) B32() string {
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if UUIDOptions.Cache && id.b32 != ""    { return id.b32 }
-    res := base32.StdEncoding.EncodeToString(id.slc)
-    if !UUIDOptions.PadB32 { res = res[0:26] }
-    id.b32 = res
-    return id.b32
+func (id KUUID) B32() string {
+    res, _ := id.Encode("b32")
+    return res
 }
 
 // URN returns hex URN of UUID, as in RFC 2141
-func (id *KUUID) URN() string {
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if UUIDOptions.Cache && id.urn != ""    { return id.urn }
-    res := []string{"urn:uuid:", id.Hex()}
-    id.urn = strings.Join(res, "")
-    return id.urn
+func (id KUUID) URN() string {
+    res, _ := id.Encode("urn")
+    return res
 }
 
 // URL64 returns URL-safe base 64 representation UUID
-func (id *KUUID) URL64() string {
-    var res string
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if UUIDOptions.Cache && id.url64 != ""  { return id.url64 }
-    if UUIDOptions.Cache && id.b64 != "" { res = id.b64 } else { res = id.B64() }
-    id.url64 = b64ToURL64(res)
-    return id.url64
+func (id KUUID) URL64() string {
+    res, _ := id.Encode("url64")
+    return res
 }
 
 // URL32 returns formatted, URL-safe base 32 representation of UUID
-func (id *KUUID) URL32() string {
-    var res string
-    if id.slc == nil || len(id.slc) == 0    { return "" }
-    if UUIDOptions.Cache && id.url32 != ""  { return id.url32 }
-    if UUIDOptions.Cache && id.b32 != "" { res = id.b32 } else { res = id.B32() }
-    res = strings.Replace(res, "=", "", -1)
-    if UUIDOptions.HyphURL32 { res = hyphenate(res, 4) }
-    id.url32 = res
-    return id.url32
+func (id KUUID) URL32() string {
+    res, _ := id.Encode("url32")
+    return res
 }
 
+// Crockford returns Crockford base 32 representation of UUID, which avoids
+// confusable characters, with a check symbol if the CrockfordCheck option is set
+func (id KUUID) Crockford() string {
+    res, _ := id.Encode("crockford")
+    return res
+}
 
-// -- Decode --
+// B62 returns fixed-width, zero-padded base 62 representation of UUID, made
+// only of letters and digits and sorting in the same order as the bytes. Read
+// it back with DecodeAs: Decode takes its 22 characters for base 64.
+func (id KUUID) B62() string {
+    res, _ := id.Encode("b62")
+    return res
+}
+
+// B36 returns fixed-width, zero-padded, lower case base 36 representation of
+// UUID, sorting in the same order as the bytes. Read it back with DecodeAs.
+func (id KUUID) B36() string {
+    res, _ := id.Encode("b36")
+    return res
+}
+
+// cached returns the string stored under key, encoding it with enc first if
+// there is none or caching is off
+func (id KUUID) cached(key string, enc func() string) string {
+    return id.cache.get(id.options().Cache, key, enc)
+}
 
-func (_ UUIDCtrl) fromA85(s string) ([]byte, error) {
-    if len(s) == 24 { s = s[2:22] }
-    if len(s) != 20 {
-        return []byte{}, errors.New("string of UUID ASCII 85 is wrong length")
+// -- Marshal --
+
+// MarshalText implements encoding.TextMarshaler; the encoding used is chosen
+// by the TextFormat option
+func (id KUUID) MarshalText() ([]byte, error) {
+    res, err := id.Encode(id.textFormat())
+    return []byte(res), err
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler; it accepts a string in
+// the TextFormat encoding or any string the UUID handler's Decode method accepts,
+// including the nil UUID, which MarshalText writes like any other
+func (id *KUUID) UnmarshalText(text []byte) error {
+    if len(text) == 0 {
+        *id = KUUID{}
+        return nil
     }
-    dst, src := make([]byte, 16), make([]byte, 16)
-    src = []byte(s)
-    _, _, err := ascii85.Decode(dst, src, true)
-    if err != nil { return []byte{}, err }
-    return dst, nil
+    res, err := id.handler().DecodeAs(id.textFormat(), string(text))
+    if err != nil && !errors.Is(err, errNilUUID) { res, err = id.handler().Decode(string(text)) }
+    if err != nil && !errors.Is(err, errNilUUID) { return err }
+    *id = res
+    return nil
 }
 
-func (_ UUIDCtrl) fromB64(s string) ([]byte, error) {
-    s = url64ToB64(s)
-    if len(s) == 22 { s = strings.Join([]string{s, "=="}, "") }
-    if len(s) != 24 {
-        return []byte{}, errors.New("string of UUID base 64 is wrong length")
+// textFormat returns the name of the encoding used for text, defaulting to hex
+func (id KUUID) textFormat() string {
+    if f := id.options().TextFormat; f != "" { return f }
+    return "hex"
+}
+
+// MarshalJSON implements json.Marshaler; an empty UUID is encoded as null
+func (id KUUID) MarshalJSON() ([]byte, error) {
+    if len(id.slc) == 0 { return []byte("null"), nil }
+    text, err := id.MarshalText()
+    if err != nil { return nil, err }
+    return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements json.Unmarshaler; null leaves an empty UUID
+func (id *KUUID) UnmarshalJSON(data []byte) error {
+    if string(data) == "null" {
+        *id = KUUID{}
+        return nil
     }
-    dst, err := base64.StdEncoding.DecodeString(s)
-    if err != nil { return []byte{}, err }
-    return dst, nil
+    var s string
+    if err := json.Unmarshal(data, &s); err != nil { return err }
+    return id.UnmarshalText([]byte(s))
 }
 
-func (_ UUIDCtrl) fromB32(s string) ([]byte, error) {
-    s = strings.Replace(s, " ", "", -1)
-    s = strings.Replace(s, "-", "", -1) 
-    s = strings.Replace(s, "=", "", -1) 
-    s = strings.ToUpper(s)
-    if len(s) != 26 {
-        return []byte{}, errors.New("string of UUID base 32 is wrong length")
+// MarshalBinary implements encoding.BinaryMarshaler; returns the 16 raw bytes,
+// or none for an empty UUID
+func (id KUUID) MarshalBinary() ([]byte, error) {
+    res := make([]byte, len(id.slc))
+    copy(res, id.slc)
+    return res, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler; takes 16 raw bytes,
+// the nil UUID included, or none for an empty UUID
+func (id *KUUID) UnmarshalBinary(data []byte) error {
+    if len(data) == 0 {
+        *id = KUUID{}
+        return nil
+    }
+    if len(data) != 16 {
+        return errors.New("binary UUID must be 16 bytes")
     }
-    s = strings.Join([]string{s, "======"}, "")
-    dst, err := base32.StdEncoding.DecodeString(s)
+    bytes := make([]byte, 16)
+    copy(bytes, data)
+    res, err := id.handler().newInst(bytes, nil)
+    if err != nil && err != errNilUUID { return err }
+    *id = res
+    return nil
+}
+
+// -- Decode --
+
+// guidSwap converts between RFC 4122 and Microsoft GUID byte order by
+// reversing the first three fields
+func guidSwap(b []byte) []byte {
+    res := make([]byte, 16)
+    copy(res, b)
+    res[0], res[1], res[2], res[3] = b[3], b[2], b[1], b[0]
+    res[4], res[5] = b[5], b[4]
+    res[6], res[7] = b[7], b[6]
+    return res
+}
+
+// uuidEncoding returns the named encoding, with the built-in ones set up
+// according to opts
+func uuidEncoding(opts *UUIDConfig, name string) (Encoding, bool) {
+    switch name {
+    case "hex":
+        return uuidHexEncoding{}, true
+    case "urn":
+        return uuidHexEncoding{urn: true}, true
+    case "a85":
+        return a85Encoding{wrap: opts.WrapA85}, true
+    case "b64":
+        return base64Encoding{pad: opts.PadB64}, true
+    case "b32":
+        return base32Encoding{pad: opts.PadB32}, true
+    case "url32":
+        return base32Encoding{hyph: opts.HyphURL32}, true
+    case "crockford":
+        return crockfordEncoding{check: opts.CrockfordCheck}, true
+    }
+    return LookupEncoding(name)
+}
+
+// uuidHexEncoding is the canonical hyphenated hex form of RFC 4122, optionally
+// as URN; when decoding, hyphens may be left out and braces or URN added
+type uuidHexEncoding struct {
+    urn bool
+}
+
+func (e uuidHexEncoding) EncodeToString(u []byte) string {
+    res := fmt.Sprintf(
+        "%08x-%04x-%04x-%04x-%012x",
+        u[:4], u[4:6], u[6:8], u[8:10], u[10:])
+    if e.urn { return strings.Join([]string{"urn:uuid:", res}, "") }
+    return res
+}
+
+func (uuidHexEncoding) DecodeString(s string, size int) ([]byte, error) {
+    dst, err := UUIDCtrl{}.fromHex(s)
     if err != nil { return []byte{}, err }
-    return dst, nil
+    return checkSize(dst, size)
 }
 
 // Copyright 2011 Google Inc.  All rights reserved.
@@ -257,26 +418,36 @@ func (_ UUIDCtrl) fromB32(s string) ([]byte, error) {
 // license that can be found in the LICENSE file.
 
 func (_ UUIDCtrl) fromHex(s string) ([]byte, error) {
-    if len(s) == 36+9 {
-        if strings.ToLower(s[:9]) != "urn:uuid:" {
-            return []byte{}, errors.New("string of UUID URN is malformed") 
-        }
-        s = s[9:]
-    } else if len(s) != 36 {
-        return []byte{}, errors.New("string of UUID is wrong length") 
+    skip := 0
+    if len(s) > 9 && strings.ToLower(s[:9]) == "urn:uuid:" {
+        s, skip = s[9:], 9
+    } else if len(s) > 2 && s[0] == '{' && s[len(s)-1] == '}' {
+        s, skip = s[1:len(s)-1], 1
     }
-    if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
-        return []byte{}, errors.New("canonical UUID string in wrong format") 
+    var octets []int
+    switch len(s) {
+    case 36:
+        for _, x := range []int{8, 13, 18, 23} {
+            if s[x] != '-' { return []byte{}, newParseError(ParseIllegalChar, x + skip) }
+        }
+        octets = []int{
+            0, 2, 4, 6,
+            9, 11,
+            14, 16,
+            19, 21,
+            24, 26, 28, 30, 32, 34}
+    case 32:
+        octets = []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24, 26, 28, 30}
+    default:
+        return []byte{}, newParseError(ParseBadLength, -1)
     }
     dst := make([]byte, 16)
-    for i, x := range []int{
-        0, 2, 4, 6,
-        9, 11,
-        14, 16,
-        19, 21,
-        24, 26, 28, 30, 32, 34} {
+    for i, x := range octets {
         v, ok := fromHexOctet(s[x:x+2])
-        if !ok { return []byte{}, errors.New("bad octet or errant cosmic ray") }
+        if !ok {
+            if _, ok = fromHexChar(s[x]); ok { x++ }
+            return []byte{}, newParseError(ParseIllegalChar, x + skip)
+        }
         dst[i] = v
     }
     return dst, nil